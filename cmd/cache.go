@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dakoctba/redup/pkg"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the persistent checksum cache",
+	Long: `Manage the persistent checksum cache used to skip re-hashing files
+whose path, size, modification time and inode have not changed.`,
+	Example: `  redup cache stats                             # Show cache location and size
+  redup cache prune                             # Remove entries of missing or changed files
+  redup cache clear --cache /tmp/redup.json     # Remove all entries from a specific cache`,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cache entries of missing or changed files",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := loadCache(cachePath)
+		if err != nil {
+			return err
+		}
+
+		removed := cache.Prune()
+		if err := cache.Save(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed %d stale entries, %d entries remaining\n", removed, cache.Stats().Entries)
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cache entries",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := resolveCachePath(cachePath)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cache %s: %v", path, err)
+		}
+
+		fmt.Printf("Cleared cache: %s\n", path)
+		return nil
+	},
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache location and number of entries",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := loadCache(cachePath)
+		if err != nil {
+			return err
+		}
+
+		size := int64(0)
+		if info, err := os.Stat(cache.Path()); err == nil {
+			size = info.Size()
+		}
+
+		fmt.Printf("Cache file: %s\n", cache.Path())
+		fmt.Printf("Entries:    %d\n", cache.Stats().Entries)
+		fmt.Printf("Size:       %d bytes\n", size)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cachePruneCmd, cacheClearCmd, cacheStatsCmd)
+}
+
+// resolveCachePath returns the cache path from the --cache flag or the default location
+func resolveCachePath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	return pkg.DefaultCachePath()
+}
+
+// loadCache loads the checksum cache. A corrupt cache is reported as an error
+// together with an empty cache that can still be used and overwritten.
+func loadCache(path string) (*pkg.ChecksumCache, error) {
+	resolved, err := resolveCachePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return pkg.LoadChecksumCache(resolved)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dakoctba/redup/pkg"
+	"github.com/spf13/cobra"
+)
+
+var checkDir string
+
+// checkIgnoreCmd represents the check-ignore command
+var checkIgnoreCmd = &cobra.Command{
+	Use:   "check-ignore PATH...",
+	Short: "Show whether paths are excluded from scans and which rule decides",
+	Long: `Report, for each path, whether a scan of the directory given with --dir
+would skip it, and the rule that decided: the file and line it came from and the
+pattern, or the --exclude/--include flag. Relative paths, and the files the
+rules come from, are relative to --dir.
+Paths are checked against .redupignore, .gitignore, .git/info/exclude,
+core.excludesFile and the --exclude, --include and --no-gitignore flags, in the
+same order of precedence as a scan. With --git-tracked, files tracked by git are
+reported as skipped or protected.`,
+	Example: `  redup check-ignore build/app.o                  # Why is this file never scanned?
+  redup check-ignore --include build/ build/app.o  # Check the effect of a flag
+  redup check-ignore -d ~/project src/main.go      # Check paths under another directory`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scanner := pkg.NewScanner(0)
+		if err := scanner.SetExcludePatterns(excludes); err != nil {
+			return err
+		}
+		if err := scanner.SetIncludePatterns(includes); err != nil {
+			return err
+		}
+		scanner.SetUseGitignore(!noGitignore)
+
+		trackedMode, err := pkg.ParseGitTrackedMode(gitTracked)
+		if err != nil {
+			return err
+		}
+		scanner.SetGitTracked(trackedMode)
+
+		// Patterns are valid; runtime errors should not print the usage text
+		cmd.SilenceUsage = true
+
+		matches, err := scanner.CheckIgnore(checkDir, args)
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, match := range matches {
+			if match.Err != nil {
+				fmt.Fprintf(os.Stderr, "%s: error: %v\n", match.Path, match.Err)
+				failed++
+				continue
+			}
+			fmt.Printf("%s: %s\n", match.Path, describeIgnoreMatch(match, trackedMode))
+		}
+
+		if failed > 0 {
+			// Execute prints the returned error; cobra should not print it again
+			cmd.SilenceErrors = true
+			return fmt.Errorf("%d of %d paths could not be checked", failed, len(matches))
+		}
+		return nil
+	},
+}
+
+// describeIgnoreMatch formats the outcome and the deciding rule as
+// "source:line:pattern", like git check-ignore -v, or the --git-tracked mode
+// that applies to a tracked file
+func describeIgnoreMatch(match pkg.IgnoreMatch, trackedMode pkg.GitTrackedMode) string {
+	if match.Tracked {
+		if trackedMode == pkg.GitTrackedSkip {
+			return "excluded by --git-tracked skip (tracked by git)"
+		}
+		return "not excluded, protected by --git-tracked protect (tracked by git)"
+	}
+
+	if match.Source == "" {
+		return "not excluded (no matching rule)"
+	}
+
+	rule := fmt.Sprintf("%s:%s", match.Source, match.Pattern)
+	if match.Line > 0 {
+		rule = fmt.Sprintf("%s:%d:%s", match.Source, match.Line, match.Pattern)
+	}
+
+	if match.Excluded {
+		return "excluded by " + rule
+	}
+	return "not excluded, included by " + rule
+}
+
+func init() {
+	checkIgnoreCmd.Flags().StringVarP(&checkDir, "dir", "d", ".", "directory the paths would be scanned from")
+	addIgnoreFlags(checkIgnoreCmd.Flags())
+	checkIgnoreCmd.Flags().StringVar(&gitTracked, "git-tracked", "", "also report files tracked by git as skipped or protected (skip|protect)")
+	rootCmd.AddCommand(checkIgnoreCmd)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dakoctba/redup/pkg"
+	"github.com/spf13/cobra"
+)
+
+var against []string
+
+// compareCmd represents the compare command
+var compareCmd = &cobra.Command{
+	Use:   "compare SRC --against DST",
+	Short: "Find files in SRC that already exist in DST",
+	Long: `Compare a source directory against one or more destination directories.
+Only duplicate groups with files on both sides are reported, and only files
+in the source can be acted on; destination files are never changed. Source
+files with no copy in the destination are listed at the end.`,
+	Example: `  redup compare /media/sdcard --against ~/Photos             # Report what is already imported
+  redup compare /media/sdcard --against ~/Photos --action trash  # Trash imported files from the card
+  redup compare ~/Inbox --against ~/Archive --against /mnt/nas   # Compare against several directories`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source := args[0]
+
+		// The source must not overlap the destinations, or its files would be protected
+		for _, dst := range against {
+			if overlaps(source, dst) {
+				return fmt.Errorf("source '%s' and destination '%s' overlap", source, dst)
+			}
+		}
+
+		opts, err := newRunOptions([]string{source}, against)
+		if err != nil {
+			return err
+		}
+
+		// Protected files are the destination side of the comparison
+		if opts.trackedMode == pkg.GitTrackedProtect {
+			return fmt.Errorf("--git-tracked protect cannot be used with compare; use --git-tracked skip")
+		}
+
+		// Arguments are valid; runtime errors should not print the usage text
+		cmd.SilenceUsage = true
+
+		// Cancel the pipeline gracefully on SIGINT/SIGTERM
+		ctx, stop := notifyContext(cmd.Context())
+		defer stop()
+
+		result, err := findDuplicates(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		matched, missing := pkg.CompareSets(result.files, result.groups)
+
+		// The JSON document is the whole output; no action is taken
+		if opts.config.JSON {
+			return pkg.ExportCompareJSON(matched, missing, os.Stdout)
+		}
+
+		// Display results
+		result.printStats()
+		if len(matched) > 0 {
+			pkg.PrintSummary(matched)
+			fmt.Println()
+		}
+		pkg.PrintMissingFiles(missing)
+
+		if len(matched) == 0 {
+			fmt.Printf("No files in %s already exist in %s.\n", source, strings.Join(against, ", "))
+			return nil
+		}
+
+		return processDuplicates(ctx, opts, matched)
+	},
+}
+
+// overlaps reports whether one directory is the same as or inside the other
+func overlaps(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	return pkg.IsWithin(absA, absB) || pkg.IsWithin(absB, absA)
+}
+
+func init() {
+	addScanFlags(compareCmd.Flags())
+	compareCmd.Flags().StringArrayVar(&against, "against", nil, "destination directory to compare against (repeatable, required)")
+	compareCmd.MarkFlagRequired("against")
+	rootCmd.AddCommand(compareCmd)
+}
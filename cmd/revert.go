@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/dakoctba/redup/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -111,6 +113,7 @@ func processRevert(logFile string, dryRun bool) error {
 	fmt.Printf("Found %d files to revert\n", len(records))
 
 	successCount := 0
+	skippedCount := 0
 	errorCount := 0
 
 	for i, record := range records {
@@ -120,9 +123,59 @@ func processRevert(logFile string, dryRun bool) error {
 			continue
 		}
 
+		keptPath := record[0]
 		movedPath := record[1]
 		backupPath := record[2]
 
+		// Older logs have no action column: every entry is a move
+		action := pkg.ActionMove
+		if len(record) > 5 && record[5] != "" {
+			action = pkg.Action(record[5])
+		}
+
+		// Deleted files cannot be restored; the entry exists for auditing only
+		if action == pkg.ActionDelete {
+			fmt.Printf("Skipped: %s was permanently deleted and cannot be restored\n", movedPath)
+			skippedCount++
+			continue
+		}
+
+		// Reflinked files are already independent copy-on-write files
+		if action == pkg.ActionReflink {
+			fmt.Printf("Skipped: %s was reflinked, nothing to revert\n", movedPath)
+			skippedCount++
+			continue
+		}
+
+		if action == pkg.ActionTrash {
+			if revertTrash(backupPath, movedPath, dryRun) {
+				successCount++
+			} else {
+				errorCount++
+			}
+			continue
+		}
+
+		if action == pkg.ActionHardlink || action == pkg.ActionSymlink {
+			// Older logs have no mode and owner columns
+			var attrs *pkg.FileAttributes
+			if len(record) > 8 {
+				attrs, err = pkg.ParseFileAttributes(record[6], record[7], record[8])
+				if err != nil {
+					fmt.Printf("Warning: invalid record at line %d: %v\n", i+2, err)
+					errorCount++
+					continue
+				}
+			}
+
+			if revertLink(keptPath, movedPath, action, attrs, dryRun) {
+				successCount++
+			} else {
+				errorCount++
+			}
+			continue
+		}
+
 		if dryRun {
 			fmt.Printf("[DRY-RUN] Would revert: %s -> %s\n", backupPath, movedPath)
 			successCount++
@@ -144,8 +197,8 @@ func processRevert(logFile string, dryRun bool) error {
 			continue
 		}
 
-		// Move file from backup to original location
-		if err := os.Rename(backupPath, movedPath); err != nil {
+		// Move file from backup to original location, copying across filesystems
+		if err := pkg.MoveFile(backupPath, movedPath); err != nil {
 			fmt.Printf("Error moving file %s: %v\n", backupPath, err)
 			errorCount++
 			continue
@@ -155,10 +208,15 @@ func processRevert(logFile string, dryRun bool) error {
 		successCount++
 	}
 
-	fmt.Printf("\nRevert completed: %d successful, %d errors\n", successCount, errorCount)
+	fmt.Printf("\nRevert completed: %d successful, %d skipped, %d errors\n", successCount, skippedCount, errorCount)
+
+	// Deleted and reflinked entries cannot be reverted; keep the log for auditing
+	if !dryRun && skippedCount > 0 {
+		fmt.Printf("Kept log file %s: it records operations that cannot be reverted\n", logFile)
+	}
 
 	// If not dry-run and all files were reverted successfully, remove the log file
-	if !dryRun && errorCount == 0 && successCount > 0 {
+	if !dryRun && errorCount == 0 && skippedCount == 0 && successCount > 0 {
 		if err := os.Remove(logFile); err != nil {
 			fmt.Printf("Warning: could not remove log file %s: %v\n", logFile, err)
 		} else {
@@ -166,14 +224,80 @@ func processRevert(logFile string, dryRun bool) error {
 		}
 
 		// Try to remove backup directory if empty
-		if len(records) > 0 {
-			backupPath := records[0][2]
-			backupDir := filepath.Dir(backupPath)
+		for _, record := range records {
+			if len(record) < 3 || record[2] == "" || (len(record) > 5 && record[5] == string(pkg.ActionTrash)) {
+				continue
+			}
+			backupDir := filepath.Dir(record[2])
 			if err := os.Remove(backupDir); err == nil {
 				fmt.Printf("Removed empty backup directory: %s\n", backupDir)
 			}
+			break
 		}
 	}
 
 	return nil
 }
+
+// revertLink replaces a link created by redup with an independent copy of the
+// kept file, with the mode and owner the duplicate had, unless the path was
+// replaced or rewritten since dedup
+func revertLink(keptPath, linkPath string, action pkg.Action, attrs *pkg.FileAttributes, dryRun bool) bool {
+	if _, err := os.Lstat(linkPath); os.IsNotExist(err) {
+		fmt.Printf("Error: linked file not found: %s\n", linkPath)
+		return false
+	}
+
+	if err := pkg.CheckLink(keptPath, linkPath, action); err != nil {
+		if errors.Is(err, pkg.ErrLinkChanged) {
+			fmt.Printf("Error: %s changed since dedup, not touching\n", linkPath)
+		} else {
+			fmt.Printf("Error checking %s: %v\n", linkPath, err)
+		}
+		return false
+	}
+
+	if dryRun {
+		fmt.Printf("[DRY-RUN] Would restore independent copy: %s\n", linkPath)
+		return true
+	}
+
+	if err := pkg.RestoreCopy(keptPath, linkPath, attrs); err != nil {
+		fmt.Printf("Error restoring copy of %s: %v\n", linkPath, err)
+		return false
+	}
+
+	fmt.Printf("Reverted: %s is an independent copy again\n", linkPath)
+	return true
+}
+
+// revertTrash restores a file moved to the trash, unless it was already restored
+func revertTrash(trashedPath, originalPath string, dryRun bool) bool {
+	if dryRun {
+		fmt.Printf("[DRY-RUN] Would restore from trash: %s -> %s\n", trashedPath, originalPath)
+		return true
+	}
+
+	if _, err := os.Lstat(trashedPath); os.IsNotExist(err) {
+		// The file may have been restored from the file manager already
+		if _, err := os.Lstat(originalPath); err == nil {
+			fmt.Printf("Already restored: %s\n", originalPath)
+			return true
+		}
+		fmt.Printf("Error: trashed file not found: %s\n", trashedPath)
+		return false
+	}
+
+	if _, err := os.Lstat(originalPath); err == nil {
+		fmt.Printf("Error: %s already exists, not overwriting\n", originalPath)
+		return false
+	}
+
+	if err := pkg.RestoreFromTrash(trashedPath, originalPath); err != nil {
+		fmt.Printf("Error restoring %s from trash: %v\n", originalPath, err)
+		return false
+	}
+
+	fmt.Printf("Reverted: %s -> %s\n", trashedPath, originalPath)
+	return true
+}
@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"runtime"
+	"strings"
 
 	"github.com/dakoctba/redup/pkg"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -15,110 +20,384 @@ var (
 	gitCommit = "unknown"
 
 	// Flags
-	dir       string
-	checksum  string
-	minSize   int64
-	backupDir string
-	dryRun    bool
-	json      bool
-	yes       bool
+	dir          string
+	checksum     string
+	minSize      int64
+	partialBytes int64
+	jobs         int
+	keepGoing    bool
+	cachePath    string
+	noCache      bool
+	backupDir    string
+	verify       bool
+	action       string
+	symlinkStyle string
+	keep         string
+	references   []string
+	protects     []string
+	stdinInput   bool
+	nullInput    bool
+	followLinks  bool
+	excludes     []string
+	includes     []string
+	noGitignore  bool
+	gitTracked   string
+	dryRun       bool
+	json         bool
+	yes          bool
+	iKnow        bool
 )
 
 // rootCmd represents the base command
 var rootCmd = &cobra.Command{
-	Use:   "redup [directory]",
+	Use:   "redup [directories...]",
 	Short: "Duplicate File Manager - Find and manage duplicate files by content",
 	Long: `redup is a command line tool that allows you to find and manage
-duplicate files by content using checksums (SHA-256 or MD5),
-respecting .gitignore rules and providing safe backup options.`,
+duplicate files by content using checksums (SHA-256 by default, or
+MD5, SHA-1, SHA-512, BLAKE3, xxh64 and xxh3), respecting .gitignore rules and providing safe backup options.`,
 	Example: `  redup --dir ~/Documents --min-size 1048576    # Scan with minimum size
   redup --checksum md5 --dry-run ~/Pictures      # Use MD5, dry run
   redup --json ~/Music > duplicates.json         # Export to JSON
-  redup --backup-dir ~/backups ~/Downloads       # Custom backup directory`,
-	Args: cobra.MaximumNArgs(1),
+  redup --backup-dir ~/backups ~/Downloads       # Custom backup directory
+  redup ~/Photos /mnt/usb/Photos                 # Find duplicates across several directories`,
+	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Se não há argumentos e nenhuma flag específica foi usada, mostrar ajuda
 		if len(args) == 0 && dir == "." && checksum == "sha256" && minSize == 0 &&
-			backupDir == "." && !dryRun && !json {
+			backupDir == "." && !dryRun && !json && !stdinInput {
 			return cmd.Help()
 		}
 
-		// Determine directory to scan
-		scanDir := dir
+		// Determine directories to scan
+		scanDirs := []string{dir}
 		if len(args) > 0 {
-			scanDir = args[0]
+			scanDirs = args
 		}
 
-		// Validate directory
-		if _, err := os.Stat(scanDir); os.IsNotExist(err) {
-			return fmt.Errorf("directory '%s' does not exist", scanDir)
-		}
+		// Reference directories are scanned but never changed
+		protectedDirs := append(append([]string{}, references...), protects...)
 
-		// Configure processor
-		config := pkg.Config{
-			Dir:       scanDir,
-			Checksum:  checksum,
-			MinSize:   minSize,
-			BackupDir: backupDir,
-			DryRun:    dryRun,
-			JSON:      json,
-			Yes:       yes,
+		// Stdin carries the file list, so it cannot also answer prompts
+		if stdinInput {
+			if len(args) > 0 {
+				return fmt.Errorf("--stdin cannot be combined with directories")
+			}
+			if !yes && !dryRun {
+				return fmt.Errorf("--stdin requires --yes or --dry-run, since stdin is not available for prompts")
+			}
+		} else if nullInput {
+			return fmt.Errorf("--null requires --stdin")
 		}
 
-		// Scan directory
-		fmt.Printf("Scanning %s...\n", scanDir)
-
-		fileScanner := pkg.NewScanner(config.MinSize)
-		files, err := fileScanner.ScanDirectory(config.Dir)
+		opts, err := newRunOptions(scanDirs, protectedDirs)
 		if err != nil {
-			return fmt.Errorf("error scanning directory: %v", err)
+			return err
 		}
+		opts.config.Stdin = stdinInput
+		opts.config.Null = nullInput
 
-		// Calculate checksums
-		hasher := pkg.NewDeduplicatorHasher(config.Checksum)
-		fileGroups, err := hasher.GroupByChecksum(files)
+		// Arguments are valid; runtime errors should not print the usage text
+		cmd.SilenceUsage = true
+
+		// Cancel the pipeline gracefully on SIGINT/SIGTERM
+		ctx, stop := notifyContext(cmd.Context())
+		defer stop()
+
+		result, err := findDuplicates(ctx, opts)
 		if err != nil {
-			return fmt.Errorf("error calculating checksums: %v", err)
+			return err
 		}
 
-		// Filter only duplicate groups
-		duplicateGroups := pkg.FilterDuplicates(fileGroups)
-
 		// Display results
-		if config.JSON {
-			pkg.ExportJSON(duplicateGroups, os.Stdout)
+		if opts.config.JSON {
+			pkg.ExportJSON(result.groups, os.Stdout)
 		} else {
-			pkg.PrintSummary(duplicateGroups)
+			result.printStats()
+			pkg.PrintSummary(result.groups)
 		}
 
-		if len(duplicateGroups) == 0 {
+		if len(result.groups) == 0 {
 			fmt.Println("No duplicate files found.")
 			return nil
 		}
 
-		// If not dry-run, ask about backup
-		if !config.DryRun {
-			backupManager := pkg.NewManager(config.BackupDir, config.Yes)
-			if err := backupManager.ProcessDuplicates(duplicateGroups); err != nil {
-				return fmt.Errorf("error processing duplicates: %v", err)
-			}
+		return processDuplicates(ctx, opts, result.groups)
+	},
+}
+
+// runOptions holds the validated configuration of a scan
+type runOptions struct {
+	config      pkg.Config
+	action      pkg.Action
+	linkStyle   pkg.SymlinkStyle
+	keepPolicy  pkg.KeepPolicy
+	trackedMode pkg.GitTrackedMode
+}
+
+// newRunOptions validates the shared scan flags before anything is scanned
+func newRunOptions(scanDirs, protectedDirs []string) (*runOptions, error) {
+	// Validate checksum algorithm before scanning
+	if !pkg.IsSupportedAlgorithm(checksum) {
+		return nil, fmt.Errorf("unsupported checksum algorithm '%s' (supported: %s)",
+			checksum, strings.Join(pkg.SupportedAlgorithms(), ", "))
+	}
+
+	// Validate action before scanning
+	duplicateAction, err := pkg.ParseAction(action)
+	if err != nil {
+		return nil, err
+	}
+	linkStyle, err := pkg.ParseSymlinkStyle(symlinkStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	keepPolicy, err := pkg.ParseKeepPolicy(keep)
+	if err != nil {
+		return nil, err
+	}
+
+	trackedMode, err := pkg.ParseGitTrackedMode(gitTracked)
+	if err != nil {
+		return nil, err
+	}
+
+	// Permanent deletion needs verification and an explicit confirmation
+	if duplicateAction == pkg.ActionDelete {
+		if !verify {
+			return nil, fmt.Errorf("--action delete requires --verify")
+		}
+		if yes && !iKnow {
+			return nil, fmt.Errorf("--action delete with --yes also requires --i-know")
+		}
+	}
+
+	// Validate directories
+	for _, scanDir := range scanDirs {
+		if _, err := os.Stat(scanDir); os.IsNotExist(err) {
+			return nil, fmt.Errorf("directory '%s' does not exist", scanDir)
+		}
+	}
+
+	for _, dir := range protectedDirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("reference directory '%s' does not exist or is not a directory", dir)
+		}
+	}
+
+	// Configure processor
+	config := pkg.Config{
+		Dir:            scanDirs[0],
+		Dirs:           scanDirs,
+		Checksum:       checksum,
+		MinSize:        minSize,
+		PartialBytes:   partialBytes,
+		Jobs:           jobs,
+		KeepGoing:      keepGoing,
+		CachePath:      cachePath,
+		NoCache:        noCache,
+		BackupDir:      backupDir,
+		Verify:         verify,
+		Action:         action,
+		SymlinkStyle:   symlinkStyle,
+		Keep:           keep,
+		ProtectedDirs:  protectedDirs,
+		DryRun:         dryRun,
+		JSON:           json,
+		Yes:            yes,
+		IKnow:          iKnow,
+		FollowSymlinks: followLinks,
+		Excludes:       excludes,
+		Includes:       includes,
+		NoGitignore:    noGitignore,
+		GitTracked:     gitTracked,
+	}
+
+	return &runOptions{
+		config:      config,
+		action:      duplicateAction,
+		linkStyle:   linkStyle,
+		keepPolicy:  keepPolicy,
+		trackedMode: trackedMode,
+	}, nil
+}
+
+// scanResult holds the output of the scan and hashing stages
+type scanResult struct {
+	files    []pkg.FileInfo
+	groups   []pkg.FileGroup
+	symlinks []string
+	hasher   *pkg.DeduplicatorHasher
+	cache    *pkg.ChecksumCache
+}
+
+// printStats prints the pipeline, symlink and cache statistics
+func (r *scanResult) printStats() {
+	pkg.PrintPipelineStats(r.hasher.Stats())
+	pkg.PrintSymlinkStats(r.symlinks)
+	if r.cache != nil {
+		pkg.PrintCacheStats(r.cache.Stats())
+	}
+}
+
+// statusOutput returns where status and progress messages go: stderr when
+// stdout carries the JSON document
+func (o *runOptions) statusOutput() io.Writer {
+	if o.config.JSON {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// findDuplicates scans the directories and groups duplicates, with the file to
+// keep first in each group
+func findDuplicates(ctx context.Context, opts *runOptions) (*scanResult, error) {
+	config := opts.config
+	status := opts.statusOutput()
+
+	fileScanner := pkg.NewScanner(config.MinSize)
+	fileScanner.SetNullSeparated(config.Null)
+	fileScanner.SetFollowSymlinks(config.FollowSymlinks)
+	if err := fileScanner.SetProtectedDirs(config.ProtectedDirs); err != nil {
+		return nil, fmt.Errorf("error resolving reference directories: %v", err)
+	}
+	if err := fileScanner.SetExcludePatterns(config.Excludes); err != nil {
+		return nil, err
+	}
+	if err := fileScanner.SetIncludePatterns(config.Includes); err != nil {
+		return nil, err
+	}
+	fileScanner.SetUseGitignore(!config.NoGitignore)
+	fileScanner.SetGitTracked(opts.trackedMode)
+
+	// Scan directories, or the file list given on stdin
+	var files []pkg.FileInfo
+	var err error
+	if config.Stdin {
+		fmt.Fprintln(status, "Reading file list from stdin...")
+		files, err = fileScanner.ScanFromStdinContext(ctx)
+		pkg.PrintScanErrors(fileScanner.Errors())
+	} else {
+		fmt.Fprintf(status, "Scanning %s...\n", strings.Join(config.Dirs, ", "))
+		files, err = fileScanner.ScanDirectoriesContext(ctx, config.Dirs)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("interrupted while scanning, no files were changed")
 		}
+		return nil, fmt.Errorf("error scanning directory: %v", err)
+	}
+
+	// Group by size and calculate checksums of the candidates
+	hasher := pkg.NewDeduplicatorHasher(config.Checksum)
+	hasher.SetPartialBytes(config.PartialBytes)
+	hasher.SetJobs(config.Jobs)
+	hasher.SetCollectErrors(config.KeepGoing)
+	hasher.SetOutput(status)
+
+	// Load the persistent checksum cache
+	var cache *pkg.ChecksumCache
+	if !config.NoCache {
+		cache, err = loadCache(config.CachePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		hasher.SetCache(cache)
+	}
+
+	duplicateGroups, err := hasher.FindDuplicatesContext(ctx, files)
 
+	// Save the cache even if hashing failed, keeping the work already done
+	if cache != nil {
+		if saveErr := cache.Save(); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", saveErr)
+		}
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("interrupted while calculating checksums, no files were changed")
+		}
+		return nil, fmt.Errorf("error calculating checksums: %v", err)
+	}
+	pkg.PrintHashErrors(hasher.Errors())
+	pkg.PrintSymlinkLoops(fileScanner.SymlinkLoops())
+
+	// Put the file to keep first in each group
+	pkg.ApplyKeepPolicy(duplicateGroups, opts.keepPolicy)
+
+	return &scanResult{
+		files:    files,
+		groups:   duplicateGroups,
+		symlinks: fileScanner.Symlinks(),
+		hasher:   hasher,
+		cache:    cache,
+	}, nil
+}
+
+// processDuplicates applies the configured action to the groups unless this is a dry run
+func processDuplicates(ctx context.Context, opts *runOptions, groups []pkg.FileGroup) error {
+	if opts.config.DryRun {
 		return nil
-	},
+	}
+
+	backupManager := pkg.NewManager(opts.config.BackupDir, opts.config.Yes)
+	backupManager.SetVerify(opts.config.Verify)
+	backupManager.SetAction(opts.action)
+	backupManager.SetSymlinkStyle(opts.linkStyle)
+	backupManager.SetDeleteConfirmed(opts.config.Yes && opts.config.IKnow)
+	if err := backupManager.ProcessDuplicatesContext(ctx, groups); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted")
+		}
+		return fmt.Errorf("error processing duplicates: %v", err)
+	}
+
+	return nil
+}
+
+// addScanFlags registers the flags shared by the commands that find duplicates
+func addScanFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&checksum, "checksum", "c", "sha256", "checksum algorithm ("+strings.Join(pkg.SupportedAlgorithms(), "|")+")")
+	flags.Int64VarP(&minSize, "min-size", "s", 0, "minimum file size to consider in bytes")
+	flags.Int64Var(&partialBytes, "partial-bytes", 4096, "bytes hashed from the start and end of each file before the full checksum (0 disables)")
+	flags.IntVar(&jobs, "jobs", runtime.NumCPU(), "number of files hashed in parallel")
+	flags.BoolVar(&keepGoing, "keep-going", false, "skip files that cannot be hashed and report them at the end instead of stopping")
+	flags.BoolVar(&followLinks, "follow-symlinks", false, "follow symbolic links to directories while scanning (loops are detected and skipped)")
+	addIgnoreFlags(flags)
+	flags.StringVar(&gitTracked, "git-tracked", "", "read the git index and skip files tracked by git, or protect them so they are always the kept copy (skip|protect)")
+	flags.BoolVar(&noCache, "no-cache", false, "do not read or update the persistent checksum cache")
+	flags.StringVarP(&backupDir, "backup-dir", "b", ".", "base directory for backup")
+	flags.StringVar(&action, "action", string(pkg.ActionMove), "what to do with each duplicate ("+strings.Join(pkg.ActionNames(), "|")+")")
+	flags.StringVar(&keep, "keep", "", "comma-separated strategies choosing the file to keep, later ones break ties ("+strings.Join(pkg.KeepStrategyNames(), "|")+")")
+	flags.StringVar(&symlinkStyle, "symlink-style", string(pkg.SymlinkRelative), "target style of links created by --action symlink (relative|absolute)")
+	flags.BoolVar(&verify, "verify", false, "compare each duplicate byte-for-byte with the kept file before moving it")
+	flags.BoolVarP(&dryRun, "dry-run", "n", false, "simulate actions without moving files")
+	flags.BoolVarP(&json, "json", "j", false, "output results in JSON format")
+	flags.BoolVarP(&yes, "yes", "y", false, "move automatically all duplicates without asking for confirmation")
+
+	flags.BoolVar(&iKnow, "i-know", false, "together with --yes, confirm --action delete without typing the confirmation phrase")
+}
+
+// addIgnoreFlags registers the flags that choose which paths are scanned
+func addIgnoreFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&excludes, "exclude", nil, "skip paths matching this gitignore-style pattern, overriding every other rule (repeatable)")
+	flags.StringArrayVar(&includes, "include", nil, "scan paths matching this gitignore-style pattern even if .redupignore or .gitignore excludes them (repeatable)")
+	flags.BoolVar(&noGitignore, "no-gitignore", false, "do not apply .gitignore, .git/info/exclude and core.excludesFile rules (.redupignore still applies)")
 }
 
 func init() {
 	rootCmd.Flags().StringVarP(&dir, "dir", "d", ".", "directory to scan (default: current working directory)")
-	rootCmd.Flags().StringVarP(&checksum, "checksum", "c", "sha256", "checksum algorithm (sha256|md5)")
-	rootCmd.Flags().Int64VarP(&minSize, "min-size", "s", 0, "minimum file size to consider in bytes")
-	rootCmd.Flags().StringVarP(&backupDir, "backup-dir", "b", ".", "base directory for backup")
-	rootCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "simulate actions without moving files")
-	rootCmd.Flags().BoolVarP(&json, "json", "j", false, "output results in JSON format")
-	rootCmd.Flags().BoolVarP(&yes, "yes", "y", false, "move automatically all duplicates without asking for confirmation")
+	addScanFlags(rootCmd.Flags())
+	rootCmd.Flags().StringArrayVar(&references, "reference", nil, "reference directory whose files are scanned and preferred as the kept copy but never changed (repeatable)")
+	rootCmd.Flags().StringArrayVar(&protects, "protect", nil, "same as --reference (repeatable)")
+	rootCmd.Flags().BoolVar(&stdinInput, "stdin", false, "read the files to check from stdin, one path per line, instead of scanning directories")
+	rootCmd.Flags().BoolVarP(&nullInput, "null", "0", false, "paths read with --stdin are separated by NUL characters (find -print0)")
 
 	rootCmd.Flags().BoolP("version", "v", false, "Show version number")
 
+	rootCmd.PersistentFlags().StringVar(&cachePath, "cache", "", "checksum cache file (default: $XDG_CACHE_HOME/redup/checksums.json)")
+
 	rootCmd.SetVersionTemplate(`{{.Name}} version {{.Version}}
 build time: ` + buildTime + `
 git commit: ` + gitCommit + `
@@ -3,11 +3,15 @@ module github.com/dakoctba/redup
 go 1.21
 
 require (
+	github.com/cespare/xxhash/v2 v2.3.0
 	github.com/joho/godotenv v1.5.1
 	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
+	github.com/zeebo/blake3 v0.2.4
+	github.com/zeebo/xxh3 v1.0.2
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
+	github.com/klauspost/cpuid/v2 v2.0.12 // indirect
 )
@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrReflinkUnsupported indica que o sistema de arquivos não suporta reflinks
+var ErrReflinkUnsupported = errors.New("filesystem does not support reflinks")
+
+// Action define o que é feito com cada arquivo duplicado
+type Action string
+
+const (
+	// ActionMove move a duplicata para o diretório de backup
+	ActionMove Action = "move"
+	// ActionHardlink substitui a duplicata por um hard link para o arquivo mantido
+	ActionHardlink Action = "hardlink"
+	// ActionSymlink substitui a duplicata por um link simbólico para o arquivo mantido
+	ActionSymlink Action = "symlink"
+	// ActionReflink faz a duplicata compartilhar as extensões do arquivo mantido
+	// (copy-on-write), sem alterar caminhos, permissões ou horários
+	ActionReflink Action = "reflink"
+	// ActionTrash move a duplicata para a lixeira do sistema (freedesktop.org)
+	ActionTrash Action = "trash"
+	// ActionDelete remove a duplicata permanentemente, sempre após verificação
+	// byte a byte. Não pode ser desfeita.
+	ActionDelete Action = "delete"
+)
+
+// DeleteConfirmationPhrase é a frase que o usuário precisa digitar para
+// confirmar a remoção permanente das duplicatas
+const DeleteConfirmationPhrase = "delete duplicates permanently"
+
+// SymlinkStyle define como o alvo dos links simbólicos é escrito
+type SymlinkStyle string
+
+const (
+	// SymlinkRelative aponta para o arquivo mantido a partir do diretório do link
+	SymlinkRelative SymlinkStyle = "relative"
+	// SymlinkAbsolute aponta para o caminho absoluto do arquivo mantido
+	SymlinkAbsolute SymlinkStyle = "absolute"
+)
+
+// actionPrompts contém a pergunta exibida antes de aplicar cada ação
+var actionPrompts = map[Action]string{
+	ActionMove:     "Move duplicate",
+	ActionHardlink: "Replace duplicate with hard link",
+	ActionSymlink:  "Replace duplicate with symlink",
+	ActionReflink:  "Share extents with reflink",
+	ActionTrash:    "Move duplicate to trash",
+	ActionDelete:   "Permanently delete duplicate",
+}
+
+// ParseAction converte o nome de uma ação, rejeitando nomes desconhecidos
+func ParseAction(name string) (Action, error) {
+	action := Action(name)
+	if _, exists := actionPrompts[action]; !exists {
+		return "", fmt.Errorf("unsupported action '%s' (supported: %s)", name, strings.Join(ActionNames(), ", "))
+	}
+	return action, nil
+}
+
+// ActionNames retorna os nomes das ações disponíveis
+func ActionNames() []string {
+	return []string{string(ActionMove), string(ActionHardlink), string(ActionSymlink), string(ActionReflink), string(ActionTrash), string(ActionDelete)}
+}
+
+// ParseSymlinkStyle converte o nome de um estilo de link simbólico
+func ParseSymlinkStyle(name string) (SymlinkStyle, error) {
+	switch style := SymlinkStyle(name); style {
+	case SymlinkRelative, SymlinkAbsolute:
+		return style, nil
+	default:
+		return "", fmt.Errorf("unsupported symlink style '%s' (supported: relative, absolute)", name)
+	}
+}
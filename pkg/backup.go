@@ -1,8 +1,9 @@
 package pkg
 
 import (
-	"bufio"
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,7 +16,28 @@ import (
 type Manager struct {
 	backupDir string
 	yes       bool
+	verify    bool
+	action    Action
+	linkStyle SymlinkStyle
 	logFile   string
+	summary   ProcessSummary
+
+	// Confirmação da remoção permanente dada por --yes --i-know
+	deleteConfirmed bool
+
+	// Dispositivos em que reflinks já falharam por falta de suporte
+	noReflink map[uint64]bool
+}
+
+// ProcessSummary contém o progresso do processamento das duplicatas
+type ProcessSummary struct {
+	Groups          int // total de grupos recebidos
+	GroupsProcessed int // grupos processados até o fim
+	FilesProcessed  int // arquivos aos quais a ação foi aplicada com sucesso
+	Skipped         int // arquivos ignorados porque a ação não é suportada
+	Protected       int // arquivos preservados por estarem em diretórios de referência
+	HardLinks       int // arquivos ignorados por serem hard links do arquivo mantido
+	Errors          int // arquivos em que a ação falhou
 }
 
 // NewManager cria uma nova instância do gerenciador de backup
@@ -26,62 +48,215 @@ func NewManager(backupDir string, yes bool) *Manager {
 	return &Manager{
 		backupDir: backupDir,
 		yes:       yes,
+		action:    ActionMove,
+		linkStyle: SymlinkRelative,
 		logFile:   logFile,
+		noReflink: make(map[uint64]bool),
 	}
 }
 
+// SetAction define a ação aplicada às duplicatas
+func (m *Manager) SetAction(action Action) {
+	m.action = action
+}
+
+// SetVerify define se cada arquivo deve ser comparado byte a byte com o arquivo
+// mantido antes de ser movido. Em caso de divergência, o arquivo não é movido.
+func (m *Manager) SetVerify(verify bool) {
+	m.verify = verify
+}
+
+// Summary retorna o progresso do último processamento
+func (m *Manager) Summary() ProcessSummary {
+	return m.summary
+}
+
+// LogFile retorna o caminho do log CSV de backup
+func (m *Manager) LogFile() string {
+	return m.logFile
+}
+
+// SetSymlinkStyle define se os links criados pela ação symlink são relativos ou absolutos
+func (m *Manager) SetSymlinkStyle(style SymlinkStyle) {
+	m.linkStyle = style
+}
+
+// SetDeleteConfirmed indica que a remoção permanente já foi confirmada pela linha
+// de comando (--yes --i-know), dispensando a frase de confirmação
+func (m *Manager) SetDeleteConfirmed(confirmed bool) {
+	m.deleteConfirmed = confirmed
+}
+
 // ProcessDuplicates processa as duplicatas e move para backup
 func (m *Manager) ProcessDuplicates(groups []FileGroup) error {
+	return m.ProcessDuplicatesContext(context.Background(), groups)
+}
+
+// ProcessDuplicatesContext processa as duplicatas como ProcessDuplicates. Quando o
+// contexto é cancelado, o arquivo atual é concluído (ou desfeito), nenhum novo
+// arquivo é movido e um resumo permitindo retomar o processamento é exibido.
+func (m *Manager) ProcessDuplicatesContext(ctx context.Context, groups []FileGroup) error {
+	m.summary = ProcessSummary{Groups: len(groups)}
+
 	if len(groups) == 0 {
 		return nil
 	}
 
-	// Criar diretório de backup automaticamente
-	backupPath, err := m.createBackupDirectory()
-	if err != nil {
-		return fmt.Errorf("failed to create backup directory: %w", err)
+	// A remoção permanente exige confirmação explícita
+	if m.action == ActionDelete && !m.confirmDelete(ctx) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("permanent deletion not confirmed")
 	}
 
-	fmt.Printf("Backup directory created: %s\n", backupPath)
+	// Criar diretório de backup automaticamente quando a ação move arquivos
+	var backupPath string
+	if m.action == ActionMove {
+		var err error
+		backupPath, err = m.createBackupDirectory()
+		if err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+
+		fmt.Printf("Backup directory created: %s\n", backupPath)
+	}
 
 	// Processar cada grupo de duplicatas
 	for i, group := range groups {
+		if ctx.Err() != nil {
+			break
+		}
+
 		fmt.Printf("\nGroup %d:\n", i+1)
 
 		// Mostrar lista numerada dos arquivos
+		links := hardLinkTargets(group.Files)
 		for j, file := range group.Files {
-			fmt.Printf("[%d] %s\n", j+1, file.Path)
+			fmt.Printf("[%d] %s\n", j+1, fileLabel(file, false, links[j]))
+		}
+
+		// Grupos formados apenas por arquivos protegidos são apenas reportados
+		if allProtected(group.Files) {
+			fmt.Println("All files are in reference directories, nothing to do.")
+			m.summary.Protected += len(group.Files)
+			m.summary.GroupsProcessed++
+			continue
 		}
 
 		// Perguntar qual arquivo manter
-		keepIndex := m.askWhichFileToKeep(len(group.Files))
+		keepIndex := m.askWhichFileToKeep(ctx, len(group.Files))
+		if ctx.Err() != nil {
+			break
+		}
 		if keepIndex < 0 {
 			fmt.Println("Skipping this group.")
+			m.summary.GroupsProcessed++
 			continue
 		}
 
-		// Mover todos os arquivos exceto o escolhido
+		// Aplicar a ação a todos os arquivos exceto o escolhido
 		for j, file := range group.Files {
 			if j == keepIndex {
 				fmt.Printf("[%d] %s (keeping)\n", j+1, file.Path)
 				continue
 			}
 
-			if m.confirmFileMove(file.Path) {
+			// Links simbólicos nunca são tratados como duplicatas
+			if info, err := os.Lstat(file.Path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				fmt.Printf("[%d] %s (symlink, skipping)\n", j+1, file.Path)
+				m.summary.Skipped++
+				continue
+			}
+
+			// Hard links do arquivo mantido não ocupam espaço adicional
+			if file.SameInode(group.Files[keepIndex]) {
+				fmt.Printf("[%d] %s (hard link of kept file, skipping)\n", j+1, file.Path)
+				m.summary.HardLinks++
+				continue
+			}
+
+			// Arquivos em diretórios de referência nunca são alterados
+			if file.Protected {
+				fmt.Printf("[%d] %s (protected, keeping)\n", j+1, file.Path)
+				m.summary.Protected++
+				continue
+			}
+
+			if m.confirmFileMove(ctx, file.Path) {
 				// Passar o caminho do arquivo que será mantido
 				keptFilePath := group.Files[keepIndex].Path
-				if err := m.moveFileToBackup(file.Path, backupPath, keptFilePath, group.Checksum); err != nil {
-					fmt.Printf("Error moving file %s: %v\n", file.Path, err)
+				result, err := m.applyAction(file.Path, backupPath, keptFilePath, group.Checksum)
+				if errors.Is(err, ErrReflinkUnsupported) {
+					fmt.Printf("Skipping %s: %v\n", file.Path, err)
+					m.summary.Skipped++
+				} else if err != nil {
+					fmt.Printf("Error processing file %s: %v\n", file.Path, err)
+					m.summary.Errors++
 				} else {
-					fmt.Printf("→ Moved to %s\n", m.getBackupPath(file.Path, backupPath))
+					fmt.Printf("→ %s\n", result)
+					m.summary.FilesProcessed++
 				}
 			}
+
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		if ctx.Err() == nil {
+			m.summary.GroupsProcessed++
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		m.printInterruptedSummary()
+		return err
+	}
+
 	return nil
 }
 
+// allProtected verifica se todos os arquivos do grupo estão em diretórios de referência
+func allProtected(files []FileInfo) bool {
+	for _, file := range files {
+		if !file.Protected {
+			return false
+		}
+	}
+	return true
+}
+
+// confirmDelete pede que o usuário digite a frase de confirmação antes de
+// qualquer remoção permanente, a menos que --yes --i-know tenha sido usado
+func (m *Manager) confirmDelete(ctx context.Context) bool {
+	if m.deleteConfirmed {
+		return true
+	}
+
+	fmt.Printf("Duplicates will be permanently deleted and cannot be restored.\n")
+	fmt.Printf("Type '%s' to continue: ", DeleteConfirmationPhrase)
+
+	input, err := readLine(ctx)
+	if err != nil {
+		fmt.Println()
+		return false
+	}
+
+	return strings.TrimSpace(input) == DeleteConfirmationPhrase
+}
+
+// printInterruptedSummary exibe o que foi feito até a interrupção e como retomar
+func (m *Manager) printInterruptedSummary() {
+	fmt.Printf("\nInterrupted: %d of %d groups processed, %d files processed, %d skipped, %d errors.\n",
+		m.summary.GroupsProcessed, m.summary.Groups, m.summary.FilesProcessed, m.summary.Skipped, m.summary.Errors)
+
+	if m.summary.FilesProcessed > 0 {
+		fmt.Printf("Every processed file is recorded in %s; run 'redup revert %s' to undo them.\n", m.logFile, m.logFile)
+	}
+	fmt.Println("Run redup again on the same directory to continue with the remaining duplicates.")
+}
+
 // createBackupDirectory cria o diretório de backup
 func (m *Manager) createBackupDirectory() (string, error) {
 	timestamp := time.Now().Format("20060102150405")
@@ -97,23 +272,78 @@ func (m *Manager) createBackupDirectory() (string, error) {
 }
 
 // confirmFileMove pergunta se deve mover um arquivo específico
-func (m *Manager) confirmFileMove(filePath string) bool {
+func (m *Manager) confirmFileMove(ctx context.Context, filePath string) bool {
 	// Se a flag --yes está ativada, move automaticamente
 	if m.yes {
 		return true
 	}
 
-	fmt.Printf("[y/N] Move duplicate: %s? ", filePath)
+	fmt.Printf("[y/N] %s: %s? ", actionPrompts[m.action], filePath)
 
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
+	input, err := readLine(ctx)
+	if err != nil {
+		fmt.Println()
+		return false
+	}
 	input = strings.TrimSpace(strings.ToLower(input))
 
 	return input == "y" || input == "yes"
 }
 
+// applyAction aplica a ação configurada a uma duplicata e retorna a descrição do resultado
+func (m *Manager) applyAction(filePath, backupPath, keptFilePath, checksum string) (string, error) {
+	switch m.action {
+	case ActionHardlink:
+		if err := m.replaceWithHardlink(filePath, keptFilePath, checksum); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Replaced with hard link to %s", keptFilePath), nil
+	case ActionSymlink:
+		target, err := m.replaceWithSymlink(filePath, keptFilePath, checksum)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Replaced with symlink to %s", target), nil
+	case ActionReflink:
+		if err := m.reflinkFile(filePath, keptFilePath, checksum); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Sharing extents with %s", keptFilePath), nil
+	case ActionTrash:
+		trashedPath, err := m.trashFile(filePath, keptFilePath, checksum)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Moved to trash: %s", trashedPath), nil
+	case ActionDelete:
+		if err := m.deleteFile(filePath, keptFilePath, checksum); err != nil {
+			return "", err
+		}
+		return "Permanently deleted", nil
+	default:
+		if err := m.moveFileToBackup(filePath, backupPath, keptFilePath, checksum); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Moved to %s", m.getBackupPath(filePath, backupPath)), nil
+	}
+}
+
+// checkDuplicate confirma, quando --verify está ativo, que o conteúdo da
+// duplicata é idêntico ao do arquivo mantido
+func (m *Manager) checkDuplicate(filePath, keptFilePath string) error {
+	if !m.verify {
+		return nil
+	}
+	return verifyDuplicate(filePath, keptFilePath)
+}
+
 // moveFileToBackup move um arquivo para o diretório de backup
 func (m *Manager) moveFileToBackup(filePath, backupPath, keptFilePath, checksum string) error {
+	// Confirmar que o conteúdo é idêntico ao do arquivo mantido
+	if err := m.checkDuplicate(filePath, keptFilePath); err != nil {
+		return err
+	}
+
 	// Obter caminhos absolutos a partir da raiz do sistema
 	absFilePath, err := filepath.Abs(filePath)
 	if err != nil {
@@ -141,11 +371,6 @@ func (m *Manager) moveFileToBackup(filePath, backupPath, keptFilePath, checksum
 		return fmt.Errorf("failed to create backup directory structure: %w", err)
 	}
 
-	// Mover o arquivo
-	if err := os.Rename(filePath, backupFilePath); err != nil {
-		return fmt.Errorf("failed to move file: %w", err)
-	}
-
 	// Obter caminho absoluto do backup também
 	absBackupPath, err := filepath.Abs(backupFilePath)
 	if err != nil {
@@ -155,16 +380,260 @@ func (m *Manager) moveFileToBackup(filePath, backupPath, keptFilePath, checksum
 		absBackupPath = "/" + absBackupPath
 	}
 
-	// Adicionar entrada no arquivo CSV
-	if err := m.addToCSV(absKeptFilePath, absFilePath, absBackupPath, checksum); err != nil {
+	// Mover o arquivo, copiando quando o backup está em outro sistema de arquivos
+	if err := MoveFile(filePath, backupFilePath); err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+
+	// Adicionar entrada no arquivo CSV, desfazendo a movimentação se falhar,
+	// para que nenhum arquivo movido fique sem registro no log
+	if err := m.addToCSV(absKeptFilePath, absFilePath, absBackupPath, checksum, ActionMove, nil); err != nil {
+		if rollbackErr := MoveFile(backupFilePath, filePath); rollbackErr != nil {
+			return fmt.Errorf("failed to add entry to CSV: %w (rollback failed, file is at %s: %v)", err, backupFilePath, rollbackErr)
+		}
 		return fmt.Errorf("failed to add entry to CSV: %w", err)
 	}
 
 	return nil
 }
 
-// addToCSV adiciona uma entrada no arquivo CSV de backup
-func (m *Manager) addToCSV(keptPath, movedPath, backupPath, checksum string) error {
+// replaceWithHardlink substitui a duplicata por um hard link para o arquivo mantido.
+// Os dois arquivos precisam estar no mesmo sistema de arquivos.
+func (m *Manager) replaceWithHardlink(filePath, keptFilePath, checksum string) error {
+	if err := m.checkDuplicate(filePath, keptFilePath); err != nil {
+		return err
+	}
+
+	absFilePath, absKeptFilePath, err := absolutePaths(filePath, keptFilePath)
+	if err != nil {
+		return err
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", filePath, err)
+	}
+	keptInfo, err := os.Stat(keptFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", keptFilePath, err)
+	}
+
+	if os.SameFile(fileInfo, keptInfo) {
+		return fmt.Errorf("already a hard link to %s", keptFilePath)
+	}
+
+	fileDev, _ := fileIdentity(fileInfo)
+	keptDev, _ := fileIdentity(keptInfo)
+	if fileDev != keptDev {
+		return fmt.Errorf("%s and %s are on different filesystems, hard link not possible", filePath, keptFilePath)
+	}
+
+	// Criar o link em um caminho temporário e substituir a duplicata atomicamente
+	if err := replaceFile(filePath, func(tmpPath string) error {
+		return os.Link(keptFilePath, tmpPath)
+	}); err != nil {
+		return fmt.Errorf("failed to create hard link: %w", err)
+	}
+
+	// Registrar no CSV, restaurando uma cópia independente se falhar
+	attrs := fileAttributes(fileInfo)
+	if err := m.addToCSV(absKeptFilePath, absFilePath, "", checksum, ActionHardlink, attrs); err != nil {
+		if rollbackErr := RestoreCopy(keptFilePath, filePath, attrs); rollbackErr != nil {
+			return fmt.Errorf("failed to add entry to CSV: %w (rollback failed: %v)", err, rollbackErr)
+		}
+		return fmt.Errorf("failed to add entry to CSV: %w", err)
+	}
+
+	return nil
+}
+
+// replaceWithSymlink substitui a duplicata por um link simbólico para o arquivo
+// mantido e retorna o alvo gravado no link. Funciona entre sistemas de arquivos.
+func (m *Manager) replaceWithSymlink(filePath, keptFilePath, checksum string) (string, error) {
+	if err := m.checkDuplicate(filePath, keptFilePath); err != nil {
+		return "", err
+	}
+
+	absFilePath, absKeptFilePath, err := absolutePaths(filePath, keptFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	if absFilePath == absKeptFilePath {
+		return "", fmt.Errorf("cannot link %s to itself", filePath)
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", filePath, err)
+	}
+	attrs := fileAttributes(fileInfo)
+
+	target := absKeptFilePath
+	if m.linkStyle == SymlinkRelative {
+		target, err = filepath.Rel(filepath.Dir(absFilePath), absKeptFilePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to compute relative path to %s: %w", keptFilePath, err)
+		}
+	}
+
+	// Criar o link em um caminho temporário e substituir a duplicata atomicamente
+	if err := replaceFile(filePath, func(tmpPath string) error {
+		return os.Symlink(target, tmpPath)
+	}); err != nil {
+		return "", fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	// Registrar no CSV, restaurando uma cópia independente se falhar
+	if err := m.addToCSV(absKeptFilePath, absFilePath, "", checksum, ActionSymlink, attrs); err != nil {
+		if rollbackErr := RestoreCopy(keptFilePath, filePath, attrs); rollbackErr != nil {
+			return "", fmt.Errorf("failed to add entry to CSV: %w (rollback failed: %v)", err, rollbackErr)
+		}
+		return "", fmt.Errorf("failed to add entry to CSV: %w", err)
+	}
+
+	return target, nil
+}
+
+// reflinkFile faz a duplicata compartilhar as extensões do arquivo mantido através
+// de um clone copy-on-write. Caminho, permissões, dono e horários não mudam. Se o
+// sistema de arquivos não suportar reflinks, retorna ErrReflinkUnsupported.
+func (m *Manager) reflinkFile(filePath, keptFilePath, checksum string) error {
+	if err := m.checkDuplicate(filePath, keptFilePath); err != nil {
+		return err
+	}
+
+	absFilePath, absKeptFilePath, err := absolutePaths(filePath, keptFilePath)
+	if err != nil {
+		return err
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", filePath, err)
+	}
+	keptInfo, err := os.Stat(keptFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", keptFilePath, err)
+	}
+
+	fileDev, _ := fileIdentity(fileInfo)
+	keptDev, _ := fileIdentity(keptInfo)
+	if fileDev != keptDev {
+		return fmt.Errorf("%w: %s and %s are on different filesystems", ErrReflinkUnsupported, filePath, keptFilePath)
+	}
+
+	// Não tentar novamente em um sistema de arquivos que já recusou o clone
+	if m.noReflink[fileDev] {
+		return ErrReflinkUnsupported
+	}
+
+	if err := reflinkReplace(keptFilePath, filePath); err != nil {
+		if errors.Is(err, ErrReflinkUnsupported) {
+			m.noReflink[fileDev] = true
+			return err
+		}
+		return fmt.Errorf("failed to reflink: %w", err)
+	}
+
+	// O clone é transparente, o registro serve apenas para auditoria
+	if err := m.addToCSV(absKeptFilePath, absFilePath, "", checksum, ActionReflink, nil); err != nil {
+		return fmt.Errorf("failed to add entry to CSV: %w", err)
+	}
+
+	return nil
+}
+
+// trashFile move a duplicata para a lixeira do sistema e retorna o caminho
+// do arquivo dentro da lixeira
+func (m *Manager) trashFile(filePath, keptFilePath, checksum string) (string, error) {
+	if err := m.checkDuplicate(filePath, keptFilePath); err != nil {
+		return "", err
+	}
+
+	absFilePath, absKeptFilePath, err := absolutePaths(filePath, keptFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	trashedPath, err := TrashFile(absFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Registrar no CSV, devolvendo o arquivo se falhar
+	if err := m.addToCSV(absKeptFilePath, absFilePath, trashedPath, checksum, ActionTrash, nil); err != nil {
+		if rollbackErr := RestoreFromTrash(trashedPath, absFilePath); rollbackErr != nil {
+			return "", fmt.Errorf("failed to add entry to CSV: %w (rollback failed, file is at %s: %v)", err, trashedPath, rollbackErr)
+		}
+		return "", fmt.Errorf("failed to add entry to CSV: %w", err)
+	}
+
+	return trashedPath, nil
+}
+
+// deleteFile remove a duplicata permanentemente. A verificação byte a byte é
+// sempre feita, independentemente de --verify. O arquivo é renomeado antes do
+// registro no CSV para que a remoção possa ser desfeita se o registro falhar.
+func (m *Manager) deleteFile(filePath, keptFilePath, checksum string) error {
+	if err := verifyDuplicate(filePath, keptFilePath); err != nil {
+		return err
+	}
+
+	absFilePath, absKeptFilePath, err := absolutePaths(filePath, keptFilePath)
+	if err != nil {
+		return err
+	}
+
+	pendingPath := tempSiblingPath(filePath)
+	if err := os.Rename(filePath, pendingPath); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	if err := m.addToCSV(absKeptFilePath, absFilePath, "", checksum, ActionDelete, nil); err != nil {
+		if rollbackErr := os.Rename(pendingPath, filePath); rollbackErr != nil {
+			return fmt.Errorf("failed to add entry to CSV: %w (rollback failed, file is at %s: %v)", err, pendingPath, rollbackErr)
+		}
+		return fmt.Errorf("failed to add entry to CSV: %w", err)
+	}
+
+	if err := os.Remove(pendingPath); err != nil {
+		return fmt.Errorf("failed to delete file, it was left at %s: %w", pendingPath, err)
+	}
+
+	return nil
+}
+
+// absolutePaths converte o caminho da duplicata e do arquivo mantido em absolutos
+func absolutePaths(filePath, keptFilePath string) (string, string, error) {
+	absFilePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get absolute path for %s: %w", filePath, err)
+	}
+
+	absKeptFilePath, err := filepath.Abs(keptFilePath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get absolute path for %s: %w", keptFilePath, err)
+	}
+
+	return absFilePath, absKeptFilePath, nil
+}
+
+// verifyDuplicate compara byte a byte um arquivo com o arquivo mantido do grupo
+func verifyDuplicate(filePath, keptFilePath string) error {
+	equal, err := FilesEqual(filePath, keptFilePath)
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+	if !equal {
+		return fmt.Errorf("verification failed: content differs from kept file %s, leaving it untouched", keptFilePath)
+	}
+	return nil
+}
+
+// addToCSV adiciona uma entrada no arquivo CSV de backup. Os atributos da
+// duplicata só são registrados quando ela é substituída por um link.
+func (m *Manager) addToCSV(keptPath, movedPath, backupPath, checksum string, action Action, attrs *FileAttributes) error {
 	// Verificar se o arquivo CSV já existe
 	fileExists := false
 	if _, err := os.Stat(m.logFile); err == nil {
@@ -179,11 +648,10 @@ func (m *Manager) addToCSV(keptPath, movedPath, backupPath, checksum string) err
 	defer file.Close()
 
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
 
 	// Se o arquivo não existia, escrever cabeçalho
 	if !fileExists {
-		header := []string{"kept_path", "moved_path", "backup_path", "checksum", "timestamp"}
+		header := []string{"kept_path", "moved_path", "backup_path", "checksum", "timestamp", "action", "mode", "uid", "gid"}
 		if err := writer.Write(header); err != nil {
 			return err
 		}
@@ -191,9 +659,17 @@ func (m *Manager) addToCSV(keptPath, movedPath, backupPath, checksum string) err
 
 	// Escrever linha de dados
 	timestamp := time.Now().Format("2006-01-02T15:04:05")
-	row := []string{keptPath, movedPath, backupPath, checksum, timestamp}
+	row := append([]string{keptPath, movedPath, backupPath, checksum, timestamp, string(action)}, attrs.logFields()...)
+	if err := writer.Write(row); err != nil {
+		return err
+	}
 
-	return writer.Write(row)
+	// Garantir que a entrada chegue ao disco antes do próximo arquivo
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return file.Sync()
 }
 
 // getBackupPath calcula o caminho do arquivo no diretório de backup
@@ -208,7 +684,7 @@ func (m *Manager) getBackupPath(filePath, backupPath string) string {
 }
 
 // askWhichFileToKeep pergunta ao usuário qual arquivo manter
-func (m *Manager) askWhichFileToKeep(fileCount int) int {
+func (m *Manager) askWhichFileToKeep(ctx context.Context, fileCount int) int {
 	// Se a flag --yes está ativada, manter o primeiro arquivo automaticamente
 	if m.yes {
 		return 0
@@ -217,8 +693,11 @@ func (m *Manager) askWhichFileToKeep(fileCount int) int {
 	for {
 		fmt.Printf("Which file to keep? (1-%d): ", fileCount)
 
-		reader := bufio.NewReader(os.Stdin)
-		input, _ := reader.ReadString('\n')
+		input, err := readLine(ctx)
+		if err != nil {
+			fmt.Println()
+			return -1
+		}
 		input = strings.TrimSpace(input)
 
 		// Permitir sair digitando 'q' ou 'quit'
@@ -0,0 +1,327 @@
+package pkg
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestManager cria um gerenciador automático com log dentro do diretório de teste
+func newTestManager(t *testing.T, dir string, action Action) *Manager {
+	t.Helper()
+	manager := NewManager(dir, true)
+	manager.SetAction(action)
+	manager.logFile = filepath.Join(dir, "log.csv")
+	return manager
+}
+
+// readLog lê as entradas do log CSV, sem o cabeçalho
+func readLog(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	return records[1:]
+}
+
+func TestParseAction(t *testing.T) {
+	if action, err := ParseAction("hardlink"); err != nil || action != ActionHardlink {
+		t.Errorf("Expected hardlink action, got %q (%v)", action, err)
+	}
+
+	if _, err := ParseAction("shred"); err == nil {
+		t.Error("Expected error for unknown action")
+	}
+}
+
+func TestReplaceWithHardlink(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_hardlink")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	kept := filepath.Join(tmpDir, "kept.txt")
+	duplicate := filepath.Join(tmpDir, "duplicate.txt")
+	os.WriteFile(kept, []byte("same content"), 0644)
+	os.WriteFile(duplicate, []byte("same content"), 0644)
+
+	manager := newTestManager(t, tmpDir, ActionHardlink)
+	manager.SetVerify(true)
+
+	if _, err := manager.applyAction(duplicate, "", kept, "checksum"); err != nil {
+		t.Fatalf("Failed to replace with hard link: %v", err)
+	}
+
+	keptInfo, _ := os.Stat(kept)
+	duplicateInfo, _ := os.Stat(duplicate)
+	if !os.SameFile(keptInfo, duplicateInfo) {
+		t.Error("Expected duplicate to be a hard link to the kept file")
+	}
+
+	records := readLog(t, manager.logFile)
+	if len(records) != 1 || records[0][5] != string(ActionHardlink) {
+		t.Fatalf("Expected one hardlink log entry, got %v", records)
+	}
+
+	// Um segundo link para o mesmo inode não libera espaço
+	if _, err := manager.applyAction(duplicate, "", kept, "checksum"); err == nil {
+		t.Error("Expected error when file is already a hard link")
+	}
+
+	// Restaurar uma cópia independente
+	if err := RestoreCopy(kept, duplicate, nil); err != nil {
+		t.Fatalf("Failed to restore copy: %v", err)
+	}
+
+	duplicateInfo, _ = os.Stat(duplicate)
+	if os.SameFile(keptInfo, duplicateInfo) {
+		t.Error("Expected restored file to be independent from the kept file")
+	}
+
+	content, _ := os.ReadFile(duplicate)
+	if string(content) != "same content" {
+		t.Errorf("Unexpected restored content: %q", content)
+	}
+}
+
+func TestReplaceWithSymlink(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_symlink")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.Mkdir(filepath.Join(tmpDir, "sub"), 0755)
+	kept := filepath.Join(tmpDir, "kept.txt")
+	os.WriteFile(kept, []byte("same content"), 0644)
+
+	tests := []struct {
+		style  SymlinkStyle
+		target string
+	}{
+		{SymlinkRelative, filepath.Join("..", "kept.txt")},
+		{SymlinkAbsolute, kept},
+	}
+
+	for _, tt := range tests {
+		duplicate := filepath.Join(tmpDir, "sub", string(tt.style)+".txt")
+		os.WriteFile(duplicate, []byte("same content"), 0644)
+
+		manager := newTestManager(t, tmpDir, ActionSymlink)
+		manager.SetSymlinkStyle(tt.style)
+
+		if _, err := manager.applyAction(duplicate, "", kept, "checksum"); err != nil {
+			t.Fatalf("Failed to replace with %s symlink: %v", tt.style, err)
+		}
+
+		target, err := os.Readlink(duplicate)
+		if err != nil {
+			t.Fatalf("Expected %s to be a symlink: %v", duplicate, err)
+		}
+		if target != tt.target {
+			t.Errorf("Expected %s symlink target %s, got %s", tt.style, tt.target, target)
+		}
+
+		// Desfazer deve produzir um arquivo regular com o mesmo conteúdo
+		if err := RestoreCopy(kept, duplicate, nil); err != nil {
+			t.Fatalf("Failed to restore copy: %v", err)
+		}
+		info, _ := os.Lstat(duplicate)
+		if !info.Mode().IsRegular() {
+			t.Errorf("Expected regular file after restore, got mode %v", info.Mode())
+		}
+	}
+}
+
+func TestReflinkFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_reflink")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	kept := filepath.Join(tmpDir, "kept.txt")
+	duplicate := filepath.Join(tmpDir, "duplicate.txt")
+	os.WriteFile(kept, []byte("same content"), 0644)
+	os.WriteFile(duplicate, []byte("same content"), 0600)
+	before, _ := os.Stat(duplicate)
+
+	// Outro hard link da duplicata deve continuar ligado a ela
+	otherLink := filepath.Join(tmpDir, "other_link.txt")
+	os.Link(duplicate, otherLink)
+
+	manager := newTestManager(t, tmpDir, ActionReflink)
+
+	_, err = manager.applyAction(duplicate, "", kept, "checksum")
+	if errors.Is(err, ErrReflinkUnsupported) {
+		// Sem suporte, a duplicata deve permanecer intacta e sem registro
+		after, _ := os.Stat(duplicate)
+		if !os.SameFile(before, after) {
+			t.Error("Expected duplicate to be untouched when reflink is unsupported")
+		}
+		if _, err := os.Stat(manager.logFile); !os.IsNotExist(err) {
+			t.Error("Expected no CSV log entry when reflink is unsupported")
+		}
+		t.Skip("filesystem does not support reflinks")
+	}
+	if err != nil {
+		t.Fatalf("Failed to reflink: %v", err)
+	}
+
+	// Permissões e mtime devem ser preservados
+	after, _ := os.Stat(duplicate)
+	if after.Mode() != before.Mode() || !after.ModTime().Equal(before.ModTime()) {
+		t.Errorf("Expected mode %v and mtime %v, got %v and %v", before.Mode(), before.ModTime(), after.Mode(), after.ModTime())
+	}
+
+	// O clone é feito no próprio arquivo, sem trocar o inode
+	if !os.SameFile(before, after) {
+		t.Error("Expected the duplicate to keep its inode")
+	}
+	if link, _ := os.Stat(otherLink); !os.SameFile(after, link) {
+		t.Error("Expected other hard links of the duplicate to stay linked")
+	}
+}
+
+func TestDeleteFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_delete")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	kept := filepath.Join(tmpDir, "kept.txt")
+	duplicate := filepath.Join(tmpDir, "duplicate.txt")
+	collision := filepath.Join(tmpDir, "collision.txt")
+	os.WriteFile(kept, []byte("same content"), 0644)
+	os.WriteFile(duplicate, []byte("same content"), 0644)
+	os.WriteFile(collision, []byte("diff content"), 0644)
+
+	// A verificação é obrigatória mesmo sem SetVerify
+	manager := newTestManager(t, tmpDir, ActionDelete)
+
+	if _, err := manager.applyAction(collision, "", kept, "checksum"); err == nil {
+		t.Error("Expected verification error for different content")
+	}
+	if _, err := os.Stat(collision); err != nil {
+		t.Error("Expected file with different content to remain")
+	}
+
+	if _, err := manager.applyAction(duplicate, "", kept, "checksum"); err != nil {
+		t.Fatalf("Failed to delete duplicate: %v", err)
+	}
+	if _, err := os.Stat(duplicate); !os.IsNotExist(err) {
+		t.Error("Expected duplicate to be deleted")
+	}
+
+	records := readLog(t, manager.logFile)
+	if len(records) != 1 || records[0][3] != "checksum" || records[0][5] != string(ActionDelete) {
+		t.Fatalf("Expected one delete log entry with checksum, got %v", records)
+	}
+
+	// Nenhum arquivo temporário deve sobrar
+	entries, _ := os.ReadDir(tmpDir)
+	if len(entries) != 3 {
+		t.Errorf("Expected kept, collision and log files only, got %d entries", len(entries))
+	}
+}
+
+func TestProcessDuplicatesProtected(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_protected")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	paths := map[string]string{}
+	for _, name := range []string{"ref1", "ref2", "dup", "ref3", "ref4"} {
+		paths[name] = filepath.Join(tmpDir, name)
+		os.WriteFile(paths[name], []byte("same content"), 0644)
+	}
+
+	groups := []FileGroup{
+		{Checksum: "a", Size: 12, Files: []FileInfo{
+			{Path: paths["dup"]},
+			{Path: paths["ref1"], Protected: true},
+			{Path: paths["ref2"], Protected: true},
+		}},
+		{Checksum: "b", Size: 12, Files: []FileInfo{
+			{Path: paths["ref3"], Protected: true},
+			{Path: paths["ref4"], Protected: true},
+		}},
+	}
+	ApplyKeepPolicy(groups, nil)
+
+	manager := newTestManager(t, tmpDir, ActionHardlink)
+	if err := manager.ProcessDuplicates(groups); err != nil {
+		t.Fatalf("ProcessDuplicates failed: %v", err)
+	}
+
+	ref1Info, _ := os.Stat(paths["ref1"])
+	dupInfo, _ := os.Stat(paths["dup"])
+	if !os.SameFile(ref1Info, dupInfo) {
+		t.Error("Expected duplicate to be linked to the protected file")
+	}
+
+	for _, name := range []string{"ref2", "ref3", "ref4"} {
+		info, _ := os.Stat(paths[name])
+		if os.SameFile(ref1Info, info) {
+			t.Errorf("Expected protected file %s to be untouched", name)
+		}
+	}
+
+	summary := manager.Summary()
+	if summary.FilesProcessed != 1 || summary.Protected != 3 {
+		t.Errorf("Expected 1 processed and 3 protected files, got %+v", summary)
+	}
+	if records := readLog(t, manager.logFile); len(records) != 1 {
+		t.Errorf("Expected one log entry, got %v", records)
+	}
+}
+
+func TestProcessDuplicatesSkipsHardLinksOfKeptFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_skip_hardlinks")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	kept := filepath.Join(tmpDir, "kept")
+	link := filepath.Join(tmpDir, "link")
+	duplicate := filepath.Join(tmpDir, "duplicate")
+	os.WriteFile(kept, []byte("same content"), 0644)
+	os.WriteFile(duplicate, []byte("same content"), 0644)
+	if err := os.Link(kept, link); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+
+	files, err := NewScanner(0).ScanDirectory(tmpDir)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+	byName := map[string]FileInfo{}
+	for _, f := range files {
+		byName[filepath.Base(f.Path)] = f
+	}
+
+	groups := []FileGroup{{Checksum: "a", Size: 12, Files: []FileInfo{byName["kept"], byName["link"], byName["duplicate"]}}}
+	manager := newTestManager(t, tmpDir, ActionHardlink)
+	if err := manager.ProcessDuplicates(groups); err != nil {
+		t.Fatalf("ProcessDuplicates failed: %v", err)
+	}
+
+	summary := manager.Summary()
+	if summary.FilesProcessed != 1 || summary.HardLinks != 1 || summary.Errors != 0 {
+		t.Errorf("Expected 1 processed file and 1 skipped hard link, got %+v", summary)
+	}
+}
@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CacheEntry representa um checksum armazenado no cache
+type CacheEntry struct {
+	Size     int64  `json:"size"`
+	ModTime  int64  `json:"mtime"`
+	Device   uint64 `json:"dev"`
+	Inode    uint64 `json:"inode"`
+	Checksum string `json:"checksum"`
+}
+
+// CacheStats contém as estatísticas de uso do cache
+type CacheStats struct {
+	Entries int
+	Hits    int
+	Misses  int
+}
+
+// ChecksumCache armazena checksums em disco para evitar recalcular arquivos
+// que não mudaram entre execuções. As entradas são indexadas pelo algoritmo e
+// pelo caminho absoluto, e só são válidas se tamanho, mtime, dispositivo e inode
+// coincidirem.
+type ChecksumCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]map[string]CacheEntry
+	hits    int
+	misses  int
+	dirty   bool
+}
+
+// DefaultCachePath retorna o caminho padrão do cache dentro do diretório de
+// cache do usuário ($XDG_CACHE_HOME no Linux)
+func DefaultCachePath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "redup", "checksums.json"), nil
+}
+
+// LoadChecksumCache carrega o cache do arquivo informado. Se o arquivo não
+// existir, retorna um cache vazio que será criado ao salvar.
+func LoadChecksumCache(path string) (*ChecksumCache, error) {
+	cache := &ChecksumCache{
+		path:    path,
+		entries: make(map[string]map[string]CacheEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cache, nil
+		}
+		return cache, fmt.Errorf("failed to read cache %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		cache.entries = make(map[string]map[string]CacheEntry)
+		return cache, fmt.Errorf("failed to parse cache %s: %w", path, err)
+	}
+
+	return cache, nil
+}
+
+// Path retorna o caminho do arquivo de cache
+func (c *ChecksumCache) Path() string {
+	return c.path
+}
+
+// Lookup procura o checksum de um arquivo, validando tamanho, mtime e inode
+func (c *ChecksumCache) Lookup(algorithm, path string, info os.FileInfo) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.entries[algorithm][path]
+	if !exists || !entry.matches(info) {
+		c.misses++
+		return "", false
+	}
+
+	c.hits++
+	return entry.Checksum, true
+}
+
+// Store registra o checksum de um arquivo no cache
+func (c *ChecksumCache) Store(algorithm, path string, info os.FileInfo, checksum string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries[algorithm] == nil {
+		c.entries[algorithm] = make(map[string]CacheEntry)
+	}
+
+	dev, ino := fileIdentity(info)
+	c.entries[algorithm][path] = CacheEntry{
+		Size:     info.Size(),
+		ModTime:  info.ModTime().UnixNano(),
+		Device:   dev,
+		Inode:    ino,
+		Checksum: checksum,
+	}
+	c.dirty = true
+}
+
+// Prune remove as entradas de arquivos que não existem mais ou que foram
+// alterados desde que foram armazenados. Retorna o número de entradas removidas.
+func (c *ChecksumCache) Prune() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for algorithm, entries := range c.entries {
+		for path, entry := range entries {
+			info, err := os.Stat(path)
+			if err != nil || !entry.matches(info) {
+				delete(entries, path)
+				removed++
+			}
+		}
+		if len(entries) == 0 {
+			delete(c.entries, algorithm)
+		}
+	}
+
+	if removed > 0 {
+		c.dirty = true
+	}
+	return removed
+}
+
+// Stats retorna o número de entradas e os acertos e falhas desde o carregamento
+func (c *ChecksumCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := 0
+	for _, algorithmEntries := range c.entries {
+		entries += len(algorithmEntries)
+	}
+
+	return CacheStats{Entries: entries, Hits: c.hits, Misses: c.misses}
+}
+
+// Save grava o cache em disco se houve alterações. A escrita é feita em um
+// arquivo temporário renomeado ao final, para não corromper o cache existente.
+func (c *ChecksumCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	// Um nome temporário único evita que execuções simultâneas misturem os arquivos
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), "."+filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// matches verifica se a entrada ainda corresponde ao estado atual do arquivo. O
+// dispositivo distingue um inode reutilizado em outro sistema de arquivos
+// montado no mesmo caminho.
+func (e CacheEntry) matches(info os.FileInfo) bool {
+	dev, ino := fileIdentity(info)
+	return e.Size == info.Size() &&
+		e.ModTime == info.ModTime().UnixNano() &&
+		e.Device == dev &&
+		e.Inode == ino
+}
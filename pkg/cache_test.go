@@ -0,0 +1,165 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChecksumCacheLookup(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_cache")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := filepath.Join(tmpDir, "file.txt")
+	os.WriteFile(file, []byte("cached content"), 0644)
+	info, _ := os.Stat(file)
+
+	cache, err := LoadChecksumCache(filepath.Join(tmpDir, "cache.json"))
+	if err != nil {
+		t.Fatalf("Failed to load empty cache: %v", err)
+	}
+
+	if _, found := cache.Lookup("sha256", file, info); found {
+		t.Error("Expected miss on empty cache")
+	}
+
+	cache.Store("sha256", file, info, "abc123")
+
+	checksum, found := cache.Lookup("sha256", file, info)
+	if !found || checksum != "abc123" {
+		t.Errorf("Expected cached checksum abc123, got %q (found=%v)", checksum, found)
+	}
+
+	// Outro algoritmo não compartilha entradas
+	if _, found := cache.Lookup("md5", file, info); found {
+		t.Error("Expected miss for different algorithm")
+	}
+
+	// O mesmo inode em outro dispositivo é outro arquivo
+	entry := cache.entries["sha256"][file]
+	entry.Device++
+	cache.entries["sha256"][file] = entry
+	if _, found := cache.Lookup("sha256", file, info); found {
+		t.Error("Expected miss for a different device")
+	}
+	cache.Store("sha256", file, info, "abc123")
+
+	// Alterar o mtime invalida a entrada
+	newTime := info.ModTime().Add(time.Hour)
+	os.Chtimes(file, newTime, newTime)
+	changed, _ := os.Stat(file)
+	if _, found := cache.Lookup("sha256", file, changed); found {
+		t.Error("Expected miss after modification time changed")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 4 || stats.Entries != 1 {
+		t.Errorf("Unexpected cache stats: %+v", stats)
+	}
+}
+
+func TestChecksumCacheSaveAndPrune(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_cache_save")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	kept := filepath.Join(tmpDir, "kept.txt")
+	removed := filepath.Join(tmpDir, "removed.txt")
+	os.WriteFile(kept, []byte("kept"), 0644)
+	os.WriteFile(removed, []byte("removed"), 0644)
+	keptInfo, _ := os.Stat(kept)
+	removedInfo, _ := os.Stat(removed)
+
+	cachePath := filepath.Join(tmpDir, "nested", "cache.json")
+	cache, _ := LoadChecksumCache(cachePath)
+	cache.Store("sha256", kept, keptInfo, "k")
+	cache.Store("sha256", removed, removedInfo, "r")
+
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Failed to save cache: %v", err)
+	}
+
+	os.Remove(removed)
+
+	reloaded, err := LoadChecksumCache(cachePath)
+	if err != nil {
+		t.Fatalf("Failed to reload cache: %v", err)
+	}
+
+	if reloaded.Stats().Entries != 2 {
+		t.Fatalf("Expected 2 entries after reload, got %d", reloaded.Stats().Entries)
+	}
+
+	if pruned := reloaded.Prune(); pruned != 1 {
+		t.Errorf("Expected 1 pruned entry, got %d", pruned)
+	}
+
+	if _, found := reloaded.Lookup("sha256", kept, keptInfo); !found {
+		t.Error("Expected entry of existing file to survive prune")
+	}
+
+	// Gravações simultâneas usam arquivos temporários distintos e não deixam restos
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		concurrent, _ := LoadChecksumCache(cachePath)
+		concurrent.Store("sha256", kept, keptInfo, fmt.Sprintf("k%d", i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := concurrent.Save(); err != nil {
+				t.Errorf("Failed to save cache concurrently: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := LoadChecksumCache(cachePath); err != nil {
+		t.Errorf("Expected a valid cache after concurrent saves: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(cachePath))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the cache file after saving, got %d entries", len(entries))
+	}
+}
+
+func TestHasherUsesCache(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_hasher_cache")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := filepath.Join(tmpDir, "file.txt")
+	os.WriteFile(file, []byte("content"), 0644)
+
+	cache, _ := LoadChecksumCache(filepath.Join(tmpDir, "cache.json"))
+	hasher := NewHasher("sha256")
+	hasher.SetCache(cache)
+
+	first, err := hasher.CalculateChecksum(file)
+	if err != nil {
+		t.Fatalf("Failed to calculate checksum: %v", err)
+	}
+
+	second, err := hasher.CalculateChecksum(file)
+	if err != nil {
+		t.Fatalf("Failed to calculate checksum: %v", err)
+	}
+
+	if first != second {
+		t.Errorf("Expected cached checksum %s, got %s", first, second)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %+v", stats)
+	}
+}
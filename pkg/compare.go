@@ -0,0 +1,39 @@
+package pkg
+
+// CompareSets separa o resultado da comparação entre uma origem e diretórios de
+// referência (arquivos protegidos). Retorna os grupos que contêm arquivos dos dois
+// lados e os arquivos da origem que não têm cópia nos diretórios de referência.
+func CompareSets(files []FileInfo, groups []FileGroup) ([]FileGroup, []FileInfo) {
+	var matched []FileGroup
+	found := make(map[string]bool)
+
+	for _, group := range groups {
+		hasSource, hasReference := false, false
+		for _, file := range group.Files {
+			if file.Protected {
+				hasReference = true
+			} else {
+				hasSource = true
+			}
+		}
+
+		if !hasSource || !hasReference {
+			continue
+		}
+
+		matched = append(matched, group)
+		for _, file := range group.Files {
+			found[file.Path] = true
+		}
+	}
+
+	// Arquivos da origem sem nenhuma cópia do outro lado, na ordem da varredura
+	var missing []FileInfo
+	for _, file := range files {
+		if !file.Protected && !found[file.Path] {
+			missing = append(missing, file)
+		}
+	}
+
+	return matched, missing
+}
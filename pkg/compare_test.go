@@ -0,0 +1,36 @@
+package pkg
+
+import "testing"
+
+func TestCompareSets(t *testing.T) {
+	files := []FileInfo{
+		{Path: "card/a"},
+		{Path: "card/b"},
+		{Path: "card/c"},
+		{Path: "card/c_copy"},
+		{Path: "lib/a", Protected: true},
+		{Path: "lib/d", Protected: true},
+		{Path: "lib/d_copy", Protected: true},
+	}
+	groups := []FileGroup{
+		{Checksum: "a", Files: []FileInfo{files[4], files[0]}},
+		{Checksum: "c", Files: []FileInfo{files[2], files[3]}},
+		{Checksum: "d", Files: []FileInfo{files[5], files[6]}},
+	}
+
+	matched, missing := CompareSets(files, groups)
+
+	if len(matched) != 1 || matched[0].Checksum != "a" {
+		t.Errorf("Expected only the group with files on both sides, got %v", matched)
+	}
+
+	expected := []string{"card/b", "card/c", "card/c_copy"}
+	if len(missing) != len(expected) {
+		t.Fatalf("Expected %d missing files, got %v", len(expected), missing)
+	}
+	for i, path := range expected {
+		if missing[i].Path != path {
+			t.Errorf("Expected missing file %s, got %s", path, missing[i].Path)
+		}
+	}
+}
@@ -2,12 +2,41 @@ package pkg
 
 // Config representa a configuração da aplicação
 type Config struct {
-	Dir       string
-	Checksum  string
-	MinSize   int64
-	BackupDir string
-	DryRun    bool
-	JSON      bool
-	Version   bool
-	Yes       bool
+	Dir          string
+	Checksum     string
+	MinSize      int64
+	PartialBytes int64
+	Jobs         int
+	KeepGoing    bool
+	CachePath    string
+	NoCache      bool
+	BackupDir    string
+	Verify       bool
+	Action       string
+	Keep         string
+	SymlinkStyle string
+	DryRun       bool
+	JSON         bool
+	Version      bool
+	Yes          bool
+	IKnow        bool
+	Stdin        bool
+	Null         bool
+
+	// Seguir links simbólicos para diretórios durante a varredura
+	FollowSymlinks bool
+
+	// Raízes de varredura; Dir é a primeira delas
+	Dirs []string
+
+	// Diretórios de referência, escaneados mas nunca alterados
+	ProtectedDirs []string
+
+	// Padrões de exclusão e inclusão e desativação das regras do git
+	Excludes    []string
+	Includes    []string
+	NoGitignore bool
+
+	// Tratamento dos arquivos rastreados pelo git (skip ou protect)
+	GitTracked string
 }
@@ -1,9 +1,15 @@
 package pkg
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // FileGroup representa um grupo de arquivos com o mesmo checksum
@@ -13,45 +19,112 @@ type FileGroup struct {
 	Size     int64
 }
 
+// PipelineStats registra quantos arquivos sobreviveram a cada etapa do pipeline
+type PipelineStats struct {
+	Scanned        int // arquivos recebidos do scanner
+	SizeMatched    int // arquivos que compartilham o tamanho com outro arquivo
+	PartialMatched int // arquivos cujo hash parcial colide com outro arquivo
+	Duplicates     int // arquivos em grupos de duplicatas após o checksum completo
+}
+
 // DeduplicatorHasher é responsável por agrupar arquivos por checksum
 type DeduplicatorHasher struct {
-	hasher *Hasher
+	hasher        *Hasher
+	partialBytes  int64
+	jobs          int
+	collectErrors bool
+	errors        []error
+	stats         PipelineStats
+	progress      progressLine
+	output        io.Writer
 }
 
 // NewDeduplicatorHasher cria uma nova instância do hasher para deduplicação
 func NewDeduplicatorHasher(algorithm string) *DeduplicatorHasher {
 	return &DeduplicatorHasher{
 		hasher: NewHasher(algorithm),
+		jobs:   runtime.NumCPU(),
+		output: os.Stdout,
+	}
+}
+
+// SetOutput define onde o progresso é exibido. O padrão é a saída padrão; a
+// saída de erro mantém limpa uma saída padrão usada para exportar resultados.
+func (h *DeduplicatorHasher) SetOutput(w io.Writer) {
+	h.output = w
+}
+
+// SetCache define o cache persistente usado no cálculo dos checksums completos
+func (h *DeduplicatorHasher) SetCache(cache *ChecksumCache) {
+	h.hasher.SetCache(cache)
+}
+
+// SetJobs define quantos arquivos podem ser processados em paralelo.
+// Valores menores que 1 usam o número de CPUs.
+func (h *DeduplicatorHasher) SetJobs(n int) {
+	if n < 1 {
+		n = runtime.NumCPU()
 	}
+	h.jobs = n
+}
+
+// SetCollectErrors define se arquivos ilegíveis devem ser ignorados, com os erros
+// acumulados em Errors, em vez de interromper o processamento no primeiro erro
+func (h *DeduplicatorHasher) SetCollectErrors(collect bool) {
+	h.collectErrors = collect
+}
+
+// Errors retorna os erros acumulados quando SetCollectErrors está ativado
+func (h *DeduplicatorHasher) Errors() []error {
+	return h.errors
+}
+
+// SetPartialBytes define quantos bytes do início e do fim de cada arquivo são
+// usados no filtro por hash parcial. Zero desativa essa etapa.
+func (h *DeduplicatorHasher) SetPartialBytes(n int64) {
+	h.partialBytes = n
+}
+
+// Stats retorna as contagens da última execução de FindDuplicates
+func (h *DeduplicatorHasher) Stats() PipelineStats {
+	return h.stats
 }
 
-// GroupByChecksum agrupa arquivos por checksum
+// GroupByChecksum agrupa arquivos por checksum. Os grupos seguem a ordem em que
+// cada checksum aparece pela primeira vez na lista de entrada.
 func (h *DeduplicatorHasher) GroupByChecksum(files []FileInfo) ([]FileGroup, error) {
+	return h.groupByChecksum(context.Background(), files)
+}
+
+// groupByChecksum implementa GroupByChecksum respeitando o cancelamento do contexto
+func (h *DeduplicatorHasher) groupByChecksum(ctx context.Context, files []FileInfo) ([]FileGroup, error) {
+	checksums, err := h.hashFiles(ctx, files, "checksum", h.hasher.CalculateChecksumContext)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(h.output) // Nova linha ao terminar
+
 	checksumMap := make(map[string][]FileInfo)
+	var order []string
 
 	for i, file := range files {
-		// Log dinâmico na mesma linha - limpa completamente a linha anterior
-		fmt.Printf("\r\033[KAnalisando: %s", file.Path)
-		checksum, err := h.hasher.CalculateChecksum(file.Path)
-		if err != nil {
-			fmt.Println() // Garante nova linha em caso de erro
-			return nil, fmt.Errorf("failed to calculate checksum for %s: %w", file.Path, err)
+		// Arquivos com erro ficam de fora quando os erros são acumulados
+		if checksums[i] == "" {
+			continue
 		}
 
-		checksumMap[checksum] = append(checksumMap[checksum], file)
-
-		// Se for o último arquivo, limpar a linha completamente
-		if i == len(files)-1 {
-			fmt.Printf("\r\033[K")
+		if _, exists := checksumMap[checksums[i]]; !exists {
+			order = append(order, checksums[i])
 		}
+		checksumMap[checksums[i]] = append(checksumMap[checksums[i]], file)
 	}
-	fmt.Println() // Nova linha ao terminar
 
 	var groups []FileGroup
-	for checksum, fileList := range checksumMap {
+	for _, checksum := range order {
+		fileList := checksumMap[checksum]
 		if len(fileList) > 0 {
 			// Ordenar arquivos por data de modificação e nome
-			sort.Slice(fileList, func(i, j int) bool {
+			sort.SliceStable(fileList, func(i, j int) bool {
 				// Se as datas são iguais, arquivos com "copy" são considerados mais novos
 				if fileList[i].ModTime.Equal(fileList[j].ModTime) {
 					// Arquivo com "copy" no nome vai para o final (será movido)
@@ -83,12 +156,211 @@ func (h *DeduplicatorHasher) GroupByChecksum(files []FileInfo) ([]FileGroup, err
 	return groups, nil
 }
 
-// FilterDuplicates retorna apenas grupos que contêm duplicatas (mais de um arquivo)
+// GroupBySize agrupa arquivos por tamanho e retorna apenas os grupos com dois ou
+// mais arquivos. Arquivos com tamanho único não podem ter duplicatas e, por isso,
+// nem precisam ter o checksum calculado. Hard links para o mesmo inode contam como
+// um único arquivo. Os grupos mantêm a ordem de entrada.
+func GroupBySize(files []FileInfo) [][]FileInfo {
+	sizeMap := make(map[int64][]FileInfo)
+	var order []int64
+
+	for _, file := range files {
+		if _, exists := sizeMap[file.Size]; !exists {
+			order = append(order, file.Size)
+		}
+		sizeMap[file.Size] = append(sizeMap[file.Size], file)
+	}
+
+	var buckets [][]FileInfo
+	for _, size := range order {
+		if distinctInodes(sizeMap[size]) > 1 {
+			buckets = append(buckets, sizeMap[size])
+		}
+	}
+
+	return buckets
+}
+
+// FindDuplicates executa o pipeline completo de deduplicação: agrupa por tamanho,
+// calcula o checksum apenas dos candidatos e retorna os grupos com duplicatas
+func (h *DeduplicatorHasher) FindDuplicates(files []FileInfo) ([]FileGroup, error) {
+	return h.FindDuplicatesContext(context.Background(), files)
+}
+
+// FindDuplicatesContext executa o pipeline como FindDuplicates, interrompendo o
+// cálculo dos checksums quando o contexto é cancelado
+func (h *DeduplicatorHasher) FindDuplicatesContext(ctx context.Context, files []FileInfo) ([]FileGroup, error) {
+	h.stats = PipelineStats{Scanned: len(files)}
+	h.errors = nil
+
+	buckets := GroupBySize(files)
+	h.stats.SizeMatched = countFiles(buckets)
+
+	if h.partialBytes > 0 {
+		var err error
+		buckets, err = h.groupByPartialChecksum(ctx, buckets)
+		if err != nil {
+			return nil, err
+		}
+	}
+	h.stats.PartialMatched = countFiles(buckets)
+
+	var candidates []FileInfo
+	for _, bucket := range buckets {
+		candidates = append(candidates, bucket...)
+	}
+
+	groups, err := h.groupByChecksum(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := FilterDuplicates(groups)
+	for _, group := range duplicates {
+		h.stats.Duplicates += len(group.Files)
+	}
+
+	return duplicates, nil
+}
+
+// groupByPartialChecksum subdivide os grupos de mesmo tamanho pelo hash do início
+// e do fim de cada arquivo, descartando os arquivos que ficam sozinhos
+func (h *DeduplicatorHasher) groupByPartialChecksum(ctx context.Context, buckets [][]FileInfo) ([][]FileInfo, error) {
+	var result [][]FileInfo
+
+	for _, bucket := range buckets {
+		// Arquivos pequenos seriam lidos por inteiro de qualquer forma; o
+		// checksum completo resolve esses grupos com a mesma quantidade de I/O
+		if bucket[0].Size <= 2*h.partialBytes {
+			result = append(result, bucket)
+			continue
+		}
+
+		checksums, err := h.hashFiles(ctx, bucket, "partial checksum", func(_ context.Context, path string) (string, error) {
+			return h.hasher.CalculatePartialChecksum(path, h.partialBytes)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		partialMap := make(map[string][]FileInfo)
+		var order []string
+
+		for i, file := range bucket {
+			if checksums[i] == "" {
+				continue
+			}
+
+			if _, exists := partialMap[checksums[i]]; !exists {
+				order = append(order, checksums[i])
+			}
+			partialMap[checksums[i]] = append(partialMap[checksums[i]], file)
+		}
+
+		for _, checksum := range order {
+			if distinctInodes(partialMap[checksum]) > 1 {
+				result = append(result, partialMap[checksum])
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// hashFiles aplica hashFn a todos os arquivos usando um pool de até h.jobs
+// goroutines. O resultado de cada arquivo fica na mesma posição da entrada, e
+// arquivos com erro ficam com checksum vazio quando os erros são acumulados.
+// Caso contrário, o processamento para no primeiro erro encontrado. O
+// cancelamento do contexto sempre interrompe o processamento. Hard links para o
+// mesmo inode são lidos uma única vez e recebem o mesmo resultado.
+func (h *DeduplicatorHasher) hashFiles(ctx context.Context, files []FileInfo, label string, hashFn func(context.Context, string) (string, error)) ([]string, error) {
+	checksums := make([]string, len(files))
+	errs := make([]error, len(files))
+
+	// Índice do primeiro arquivo de cada inode, que representa os demais
+	representative := make([]int, len(files))
+	firstIndex := make(map[fileKey]int, len(files))
+	for i, file := range files {
+		if first, exists := firstIndex[file.key()]; exists {
+			representative[i] = first
+			continue
+		}
+		firstIndex[file.key()] = i
+		representative[i] = i
+	}
+
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+
+	for w := 0; w < min(h.jobs, len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				// Log dinâmico na mesma linha
+				h.progress.Update(h.output, files[i].Path)
+
+				checksum, err := hashFn(ctx, files[i].Path)
+				if err != nil {
+					errs[i] = fmt.Errorf("failed to calculate %s for %s: %w", label, files[i].Path, err)
+					if !h.collectErrors {
+						failed.Store(true)
+					}
+					continue
+				}
+				checksums[i] = checksum
+			}
+		}()
+	}
+
+	for i := range files {
+		// Parar de distribuir trabalho após o primeiro erro ou cancelamento
+		if failed.Load() || ctx.Err() != nil {
+			break
+		}
+		if representative[i] != i {
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	h.progress.Clear(h.output)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, first := range representative {
+		checksums[i] = checksums[first]
+	}
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if !h.collectErrors {
+			return nil, err
+		}
+		h.errors = append(h.errors, err)
+	}
+
+	return checksums, nil
+}
+
+// FilterDuplicates retorna apenas grupos que contêm duplicatas (mais de um arquivo).
+// Grupos formados apenas por hard links do mesmo inode não liberam espaço e ficam de fora.
 func FilterDuplicates(groups []FileGroup) []FileGroup {
 	var duplicates []FileGroup
 
 	for _, group := range groups {
-		if len(group.Files) > 1 {
+		if len(group.Files) > 1 && distinctInodes(group.Files) > 1 {
 			duplicates = append(duplicates, group)
 		}
 	}
@@ -101,12 +373,65 @@ func GetTotalDuplicateSize(groups []FileGroup) int64 {
 	var total int64
 
 	for _, group := range groups {
-		if len(group.Files) > 1 {
-			// Calcular espaço que pode ser liberado (todos menos um arquivo)
-			total += group.Size * int64(len(group.Files)-1)
+		total += group.Size * int64(reclaimableInodes(group.Files))
+	}
+
+	return total
+}
+
+// reclaimableInodes conta os inodes de um grupo que podem ser liberados: todos
+// menos o do arquivo mantido, sem contar arquivos protegidos, que nunca são
+// alterados, nem hard links, que compartilham os dados com outro arquivo do grupo
+func reclaimableInodes(files []FileInfo) int {
+	if len(files) < 2 {
+		return 0
+	}
+
+	kept := make(map[fileKey]bool)
+	for _, file := range files {
+		if file.Protected {
+			kept[file.key()] = true
 		}
 	}
+	if len(kept) == 0 {
+		kept[files[0].key()] = true
+	}
 
+	reclaimable := make(map[fileKey]bool)
+	for _, file := range files {
+		if !kept[file.key()] {
+			reclaimable[file.key()] = true
+		}
+	}
+	return len(reclaimable)
+}
+
+// progressLine exibe o arquivo em análise em uma única linha do terminal. O mutex
+// evita que várias goroutines intercalem as sequências de controle.
+type progressLine struct {
+	mu sync.Mutex
+}
+
+// Update substitui o conteúdo da linha pelo caminho informado
+func (p *progressLine) Update(w io.Writer, path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(w, "\r\033[KAnalisando: %s", path)
+}
+
+// Clear limpa completamente a linha de progresso
+func (p *progressLine) Clear(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(w, "\r\033[K")
+}
+
+// countFiles conta o total de arquivos em uma lista de grupos
+func countFiles(buckets [][]FileInfo) int {
+	total := 0
+	for _, bucket := range buckets {
+		total += len(bucket)
+	}
 	return total
 }
 
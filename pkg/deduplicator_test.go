@@ -1,6 +1,9 @@
 package pkg
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -148,3 +151,291 @@ func TestFilterDuplicatesNoDuplicates(t *testing.T) {
 		t.Errorf("Expected 0 duplicate groups, got %d", len(duplicates))
 	}
 }
+
+func TestGroupBySize(t *testing.T) {
+	files := []FileInfo{
+		{Path: "a.txt", Size: 100},
+		{Path: "b.txt", Size: 200},
+		{Path: "c.txt", Size: 100},
+		{Path: "d.txt", Size: 300},
+		{Path: "e.txt", Size: 300},
+		{Path: "f.txt", Size: 300},
+	}
+
+	buckets := GroupBySize(files)
+
+	// Apenas os tamanhos 100 e 300 têm mais de um arquivo
+	if len(buckets) != 2 {
+		t.Fatalf("Expected 2 size buckets, got %d", len(buckets))
+	}
+
+	if len(buckets[0]) != 2 || buckets[0][0].Path != "a.txt" || buckets[0][1].Path != "c.txt" {
+		t.Errorf("Unexpected first bucket: %v", buckets[0])
+	}
+
+	if len(buckets[1]) != 3 {
+		t.Errorf("Expected 3 files in second bucket, got %d", len(buckets[1]))
+	}
+}
+
+func TestFindDuplicatesMatchesFilterDuplicates(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_find_dup")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Dois pares de duplicatas, um arquivo do mesmo tamanho com conteúdo
+	// diferente e um arquivo de tamanho único
+	contents := map[string]string{
+		"a1.txt":     "aaaa",
+		"a2.txt":     "aaaa",
+		"b1.txt":     "bbbbbbbb",
+		"b2.txt":     "bbbbbbbb",
+		"c.txt":      "cccc",
+		"unique.txt": "unique size",
+	}
+
+	var fileInfos []FileInfo
+	for name, content := range contents {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", path, err)
+		}
+		fileInfos = append(fileInfos, FileInfo{Path: path, Size: int64(len(content))})
+	}
+
+	hasher := NewDeduplicatorHasher("sha256")
+
+	groups, err := hasher.GroupByChecksum(fileInfos)
+	if err != nil {
+		t.Fatalf("Failed to group by checksum: %v", err)
+	}
+	expected := FilterDuplicates(groups)
+
+	duplicates, err := hasher.FindDuplicates(fileInfos)
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+
+	if len(duplicates) != len(expected) {
+		t.Fatalf("Expected %d duplicate groups, got %d", len(expected), len(duplicates))
+	}
+
+	expectedByChecksum := make(map[string]FileGroup)
+	for _, group := range expected {
+		expectedByChecksum[group.Checksum] = group
+	}
+
+	for _, group := range duplicates {
+		want, ok := expectedByChecksum[group.Checksum]
+		if !ok {
+			t.Errorf("Unexpected group with checksum %s", group.Checksum)
+			continue
+		}
+		if len(group.Files) != len(want.Files) {
+			t.Errorf("Expected %d files in group %s, got %d", len(want.Files), group.Checksum, len(group.Files))
+		}
+	}
+}
+
+func TestFindDuplicatesPartialStats(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_partial_stats")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Quatro arquivos do mesmo tamanho: dois idênticos, um que difere no
+	// início e outro que difere apenas no meio
+	base := make([]byte, 256)
+	differentHead := append([]byte{}, base...)
+	differentHead[0] = 1
+	differentMiddle := append([]byte{}, base...)
+	differentMiddle[128] = 1
+
+	contents := map[string][]byte{
+		"a.bin":      base,
+		"b.bin":      base,
+		"head.bin":   differentHead,
+		"middle.bin": differentMiddle,
+		"small.bin":  []byte("small"),
+	}
+
+	var fileInfos []FileInfo
+	for name, content := range contents {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", path, err)
+		}
+		fileInfos = append(fileInfos, FileInfo{Path: path, Size: int64(len(content))})
+	}
+
+	hasher := NewDeduplicatorHasher("sha256")
+	hasher.SetPartialBytes(16)
+
+	duplicates, err := hasher.FindDuplicates(fileInfos)
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+
+	if len(duplicates) != 1 || len(duplicates[0].Files) != 2 {
+		t.Fatalf("Expected 1 group with 2 files, got %v", duplicates)
+	}
+
+	expected := PipelineStats{Scanned: 5, SizeMatched: 4, PartialMatched: 3, Duplicates: 2}
+	if hasher.Stats() != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, hasher.Stats())
+	}
+}
+
+func TestGroupByChecksumConcurrentOrder(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_concurrent")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Vários grupos de duplicatas intercalados na entrada
+	var fileInfos []FileInfo
+	for i := 0; i < 30; i++ {
+		path := filepath.Join(tmpDir, fmt.Sprintf("file%02d.txt", i))
+		content := fmt.Sprintf("content %d", i%5)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", path, err)
+		}
+		fileInfos = append(fileInfos, FileInfo{Path: path, Size: int64(len(content))})
+	}
+
+	hasher := NewDeduplicatorHasher("sha256")
+	hasher.SetJobs(8)
+
+	groups, err := hasher.GroupByChecksum(fileInfos)
+	if err != nil {
+		t.Fatalf("Failed to group by checksum: %v", err)
+	}
+
+	if len(groups) != 5 {
+		t.Fatalf("Expected 5 groups, got %d", len(groups))
+	}
+
+	// Os grupos seguem a ordem da primeira ocorrência e os arquivos a ordem de entrada
+	for i, group := range groups {
+		if group.Files[0].Path != fileInfos[i].Path {
+			t.Errorf("Expected group %d to start with %s, got %s", i, fileInfos[i].Path, group.Files[0].Path)
+		}
+		if len(group.Files) != 6 {
+			t.Errorf("Expected 6 files in group %d, got %d", i, len(group.Files))
+		}
+	}
+}
+
+func TestGroupByChecksumErrors(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_hash_errors")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file1 := filepath.Join(tmpDir, "file1.txt")
+	file2 := filepath.Join(tmpDir, "file2.txt")
+	os.WriteFile(file1, []byte("same"), 0644)
+	os.WriteFile(file2, []byte("same"), 0644)
+
+	fileInfos := []FileInfo{
+		{Path: file1, Size: 4},
+		{Path: filepath.Join(tmpDir, "missing.txt"), Size: 4},
+		{Path: file2, Size: 4},
+	}
+
+	// Por padrão, o primeiro erro interrompe o processamento
+	hasher := NewDeduplicatorHasher("sha256")
+	if _, err := hasher.GroupByChecksum(fileInfos); err == nil {
+		t.Error("Expected error for missing file")
+	}
+
+	// Acumulando erros, o arquivo ilegível é ignorado
+	hasher.SetCollectErrors(true)
+	groups, err := hasher.GroupByChecksum(fileInfos)
+	if err != nil {
+		t.Fatalf("Expected no error when collecting errors, got: %v", err)
+	}
+
+	if len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Errorf("Expected 1 group with 2 files, got %v", groups)
+	}
+
+	if len(hasher.Errors()) != 1 {
+		t.Errorf("Expected 1 collected error, got %d", len(hasher.Errors()))
+	}
+}
+
+func TestFindDuplicatesContextCancelled(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_cancel")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var fileInfos []FileInfo
+	for i := 0; i < 4; i++ {
+		path := filepath.Join(tmpDir, fmt.Sprintf("file%d.txt", i))
+		os.WriteFile(path, []byte("same"), 0644)
+		fileInfos = append(fileInfos, FileInfo{Path: path, Size: 4})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	hasher := NewDeduplicatorHasher("sha256")
+	if _, err := hasher.FindDuplicatesContext(ctx, fileInfos); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFindDuplicatesHardLinks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_hardlinks")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// "a" e "a_link" são o mesmo inode; "b" é uma cópia real; "c" e "c_link"
+	// só têm hard links entre si e não são duplicatas
+	os.WriteFile(filepath.Join(tmpDir, "a"), []byte("same content"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "b"), []byte("same content"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "c"), []byte("other content"), 0644)
+	if err := os.Link(filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "a_link")); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+	os.Link(filepath.Join(tmpDir, "c"), filepath.Join(tmpDir, "c_link"))
+
+	files, err := NewScanner(0).ScanDirectory(tmpDir)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+
+	groups, err := NewDeduplicatorHasher("sha256").FindDuplicates(files)
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+
+	if len(groups) != 1 || len(groups[0].Files) != 3 {
+		t.Fatalf("Expected one group with a, a_link and b, got %v", groups)
+	}
+
+	// Apenas a cópia real libera espaço
+	if size := GetTotalDuplicateSize(groups); size != 12 {
+		t.Errorf("Expected 12 reclaimable bytes, got %d", size)
+	}
+
+	// O número de duplicatas do resumo concorda com o espaço liberado
+	if count := reclaimableInodes(groups[0].Files); count != 1 {
+		t.Errorf("Expected 1 reclaimable duplicate, got %d", count)
+	}
+
+	links := hardLinkTargets(groups[0].Files)
+	if len(links) != 1 {
+		t.Errorf("Expected one file marked as hard link, got %v", links)
+	}
+}
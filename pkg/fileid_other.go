@@ -0,0 +1,20 @@
+//go:build !unix
+
+package pkg
+
+import "os"
+
+// fileIdentity retorna zero em plataformas sem dispositivo e inode
+func fileIdentity(info os.FileInfo) (dev, ino uint64) {
+	return 0, 0
+}
+
+// fileLinkCount considera um único link em plataformas sem essa informação
+func fileLinkCount(info os.FileInfo) uint64 {
+	return 1
+}
+
+// fileOwner retorna -1 em plataformas sem dono numérico
+func fileOwner(info os.FileInfo) (uid, gid int) {
+	return -1, -1
+}
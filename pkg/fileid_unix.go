@@ -0,0 +1,32 @@
+//go:build unix
+
+package pkg
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIdentity retorna o dispositivo e o inode de um arquivo
+func fileIdentity(info os.FileInfo) (dev, ino uint64) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev), uint64(stat.Ino)
+	}
+	return 0, 0
+}
+
+// fileLinkCount retorna o número de hard links de um arquivo
+func fileLinkCount(info os.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Nlink)
+	}
+	return 1
+}
+
+// fileOwner retorna o dono e o grupo de um arquivo, ou -1 se desconhecidos
+func fileOwner(info os.FileInfo) (uid, gid int) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int(stat.Uid), int(stat.Gid)
+	}
+	return -1, -1
+}
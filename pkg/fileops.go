@@ -0,0 +1,321 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// MoveFile move src para dst. Quando o rename falha porque os caminhos estão em
+// sistemas de arquivos diferentes (EXDEV), o arquivo é copiado, sincronizado com
+// o disco e conferido por checksum, preservando modo, mtime e dono, e só então
+// a origem é removida. Em caso de falha, a origem permanece intacta.
+func MoveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	return moveByCopy(src, dst)
+}
+
+// moveByCopy implementa a movimentação entre sistemas de arquivos
+func moveByCopy(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("cannot move %s across filesystems: not a regular file", src)
+	}
+
+	// Copiar para um caminho temporário ao lado do destino
+	tmpPath := tempSiblingPath(dst)
+	if err := copyFileContents(src, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+
+	if err := preserveOwner(tmpPath, info); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to preserve ownership of %s: %w", src, err)
+	}
+
+	// O chmod vem depois do chown, que limpa os bits setuid e setgid, e não
+	// depende da umask aplicada na criação da cópia
+	if err := os.Chmod(tmpPath, info.Mode()); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to preserve mode of %s: %w", src, err)
+	}
+
+	// Conferir que a cópia é idêntica antes de remover a origem
+	hasher := NewHasher("sha256")
+	srcChecksum, err := hasher.CalculateChecksum(src)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	dstChecksum, err := hasher.CalculateChecksum(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if srcChecksum != dstChecksum {
+		os.Remove(tmpPath)
+		return fmt.Errorf("checksum mismatch after copying %s to %s", src, dst)
+	}
+
+	if err := os.Rename(tmpPath, dst); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := syncDir(filepath.Dir(dst)); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+// syncDir sincroniza um diretório com o disco, garantindo que uma entrada recém
+// renomeada sobreviva a uma queda de energia
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Alguns sistemas não permitem sincronizar diretórios; isso não é fatal
+	if err := d.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTSUP) {
+		return err
+	}
+	return nil
+}
+
+// tempSiblingPath retorna um caminho temporário no mesmo diretório de path, para
+// que a substituição final possa ser feita com um rename atômico
+func tempSiblingPath(path string) string {
+	dir, base := filepath.Split(path)
+	return filepath.Join(dir, fmt.Sprintf(".%s.redup-%d", base, time.Now().UnixNano()))
+}
+
+// replaceFile substitui path atomicamente pelo arquivo que create produz em um
+// caminho temporário. Se algo falhar, path permanece intacto.
+func replaceFile(path string, create func(tmpPath string) error) error {
+	tmpPath := tempSiblingPath(path)
+
+	if err := create(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+// copyFileContents copia o conteúdo de src para um novo arquivo dst, preservando
+// o modo e o mtime de src, e garante que os dados cheguem ao disco
+func copyFileContents(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// RestoreCopy substitui path por uma cópia independente de source. É usado para
+// desfazer a substituição de duplicatas por links. A cópia recebe o modo e o dono
+// registrados da duplicata original; sem eles (logs antigos), o modo de source.
+func RestoreCopy(source, path string, attrs *FileAttributes) error {
+	return replaceFile(path, func(tmpPath string) error {
+		if err := copyFileContents(source, tmpPath); err != nil {
+			return err
+		}
+
+		if attrs == nil {
+			info, err := os.Stat(source)
+			if err != nil {
+				return err
+			}
+			return os.Chmod(tmpPath, info.Mode())
+		}
+
+		// O chown limpa os bits setuid e setgid, por isso o chmod vem depois
+		if err := setOwner(tmpPath, attrs.UID, attrs.GID); err != nil {
+			return err
+		}
+		return os.Chmod(tmpPath, attrs.Mode)
+	})
+}
+
+// FileAttributes são o modo e o dono de uma duplicata substituída por um link,
+// registrados no log para que o revert restaure o arquivo como era
+type FileAttributes struct {
+	Mode os.FileMode
+	UID  int // -1 quando desconhecido
+	GID  int // -1 quando desconhecido
+}
+
+// fileAttributes lê o modo, incluindo os bits especiais, e o dono de um arquivo
+func fileAttributes(info os.FileInfo) *FileAttributes {
+	uid, gid := fileOwner(info)
+	mode := info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	return &FileAttributes{Mode: mode, UID: uid, GID: gid}
+}
+
+// Bits especiais do modo no formato octal do chmod
+const (
+	modeSetuid = 04000
+	modeSetgid = 02000
+	modeSticky = 01000
+)
+
+// logFields formata os atributos para as colunas mode, uid e gid do log, com o
+// modo em octal como no chmod. Atributos ausentes ficam vazios.
+func (a *FileAttributes) logFields() []string {
+	if a == nil {
+		return []string{"", "", ""}
+	}
+
+	mode := uint32(a.Mode.Perm())
+	if a.Mode&os.ModeSetuid != 0 {
+		mode |= modeSetuid
+	}
+	if a.Mode&os.ModeSetgid != 0 {
+		mode |= modeSetgid
+	}
+	if a.Mode&os.ModeSticky != 0 {
+		mode |= modeSticky
+	}
+	return []string{fmt.Sprintf("%04o", mode), strconv.Itoa(a.UID), strconv.Itoa(a.GID)}
+}
+
+// ParseFileAttributes lê as colunas mode, uid e gid do log. Entradas sem modo,
+// de ações que não registram atributos ou de logs antigos, retornam nil.
+func ParseFileAttributes(mode, uid, gid string) (*FileAttributes, error) {
+	if mode == "" {
+		return nil, nil
+	}
+
+	bits, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || bits > 07777 {
+		return nil, fmt.Errorf("invalid mode '%s'", mode)
+	}
+	attrs := &FileAttributes{Mode: os.FileMode(bits).Perm(), UID: -1, GID: -1}
+	if bits&modeSetuid != 0 {
+		attrs.Mode |= os.ModeSetuid
+	}
+	if bits&modeSetgid != 0 {
+		attrs.Mode |= os.ModeSetgid
+	}
+	if bits&modeSticky != 0 {
+		attrs.Mode |= os.ModeSticky
+	}
+
+	if uid != "" && gid != "" {
+		if attrs.UID, err = strconv.Atoi(uid); err != nil {
+			return nil, fmt.Errorf("invalid uid '%s'", uid)
+		}
+		if attrs.GID, err = strconv.Atoi(gid); err != nil {
+			return nil, fmt.Errorf("invalid gid '%s'", gid)
+		}
+	}
+	return attrs, nil
+}
+
+// preserveOwner aplica a path o dono e o grupo de info
+func preserveOwner(path string, info os.FileInfo) error {
+	uid, gid := fileOwner(info)
+	return setOwner(path, uid, gid)
+}
+
+// setOwner aplica a path o dono e o grupo informados, se forem conhecidos e
+// diferentes dos do processo atual, que é o dono de um arquivo recém-criado
+func setOwner(path string, uid, gid int) error {
+	if uid < 0 || gid < 0 || (uid == os.Geteuid() && gid == os.Getegid()) {
+		return nil
+	}
+	return os.Lchown(path, uid, gid)
+}
+
+// ErrLinkChanged indica que um caminho não é mais o link criado pelo redup
+var ErrLinkChanged = errors.New("changed since dedup, not touching")
+
+// CheckLink confere que linkPath ainda é o link para keptPath criado pela ação,
+// antes de substituí-lo por uma cópia. Um arquivo substituído ou reescrito
+// depois da deduplicação retorna ErrLinkChanged.
+func CheckLink(keptPath, linkPath string, action Action) error {
+	keptInfo, err := os.Lstat(keptPath)
+	if err != nil {
+		return err
+	}
+	linkInfo, err := os.Lstat(linkPath)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case ActionHardlink:
+		if !linkInfo.Mode().IsRegular() || !os.SameFile(keptInfo, linkInfo) {
+			return fmt.Errorf("%s: %w", linkPath, ErrLinkChanged)
+		}
+	case ActionSymlink:
+		if linkInfo.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("%s: %w", linkPath, ErrLinkChanged)
+		}
+
+		// Alvos relativos são resolvidos a partir do diretório do link
+		target, err := os.Readlink(linkPath)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(linkPath), target)
+		}
+
+		absTarget, err := filepath.Abs(target)
+		if err != nil {
+			return err
+		}
+		absKept, err := filepath.Abs(keptPath)
+		if err != nil {
+			return err
+		}
+		if absTarget != absKept {
+			return fmt.Errorf("%s: %w", linkPath, ErrLinkChanged)
+		}
+	}
+	return nil
+}
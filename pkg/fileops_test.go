@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMoveFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_move")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src.txt")
+	dst := filepath.Join(tmpDir, "dst.txt")
+	os.WriteFile(src, []byte("content"), 0644)
+
+	if err := MoveFile(src, dst); err != nil {
+		t.Fatalf("MoveFile failed: %v", err)
+	}
+
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("Expected source to be removed")
+	}
+	if content, _ := os.ReadFile(dst); string(content) != "content" {
+		t.Errorf("Unexpected destination content: %q", content)
+	}
+}
+
+func TestMoveByCopy(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_move_copy")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "src.txt")
+	dst := filepath.Join(tmpDir, "backup", "dst.txt")
+	os.Mkdir(filepath.Dir(dst), 0755)
+	os.WriteFile(src, []byte("content copied across devices"), 0640)
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	os.Chtimes(src, mtime, mtime)
+
+	// Simular o caminho usado quando o rename falha com EXDEV
+	if err := moveByCopy(src, dst); err != nil {
+		t.Fatalf("moveByCopy failed: %v", err)
+	}
+
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("Expected source to be removed after copy")
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatalf("Expected destination to exist: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected mode 0640, got %v", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("Expected mtime %v, got %v", mtime, info.ModTime())
+	}
+
+	// Nenhum arquivo temporário deve sobrar no destino
+	entries, _ := os.ReadDir(filepath.Dir(dst))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the moved file in destination, got %d entries", len(entries))
+	}
+}
+
+func TestCheckLinkHardlink(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_check_hardlink")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	kept := filepath.Join(tmpDir, "kept.txt")
+	linked := filepath.Join(tmpDir, "linked.txt")
+	os.WriteFile(kept, []byte("content"), 0644)
+	if err := os.Link(kept, linked); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+
+	if err := CheckLink(kept, linked, ActionHardlink); err != nil {
+		t.Errorf("Expected the hard link to be accepted, got %v", err)
+	}
+
+	// O usuário substituiu o link por um arquivo com outro conteúdo
+	os.Remove(linked)
+	os.WriteFile(linked, []byte("user data"), 0644)
+
+	if err := CheckLink(kept, linked, ActionHardlink); !errors.Is(err, ErrLinkChanged) {
+		t.Errorf("Expected ErrLinkChanged, got %v", err)
+	}
+	if content, _ := os.ReadFile(linked); string(content) != "user data" {
+		t.Errorf("Expected the replaced file to be untouched, got %q", content)
+	}
+}
+
+func TestCheckLinkSymlink(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_check_symlink")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755)
+	kept := filepath.Join(tmpDir, "kept.txt")
+	other := filepath.Join(tmpDir, "other.txt")
+	linked := filepath.Join(tmpDir, "sub", "linked.txt")
+	os.WriteFile(kept, []byte("content"), 0644)
+	os.WriteFile(other, []byte("content"), 0644)
+
+	// Links relativos e absolutos para o arquivo mantido são aceitos
+	for _, target := range []string{filepath.Join("..", "kept.txt"), kept} {
+		os.Remove(linked)
+		if err := os.Symlink(target, linked); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+		if err := CheckLink(kept, linked, ActionSymlink); err != nil {
+			t.Errorf("Expected the symlink to %s to be accepted, got %v", target, err)
+		}
+	}
+
+	// Um link para outro arquivo não é o link criado pelo redup
+	os.Remove(linked)
+	os.Symlink(filepath.Join("..", "other.txt"), linked)
+	if err := CheckLink(kept, linked, ActionSymlink); !errors.Is(err, ErrLinkChanged) {
+		t.Errorf("Expected ErrLinkChanged for a link to another file, got %v", err)
+	}
+
+	// O usuário substituiu o link por um arquivo real
+	os.Remove(linked)
+	os.WriteFile(linked, []byte("user data"), 0644)
+	if err := CheckLink(kept, linked, ActionSymlink); !errors.Is(err, ErrLinkChanged) {
+		t.Errorf("Expected ErrLinkChanged for a regular file, got %v", err)
+	}
+	if content, _ := os.ReadFile(linked); string(content) != "user data" {
+		t.Errorf("Expected the replaced file to be untouched, got %q", content)
+	}
+}
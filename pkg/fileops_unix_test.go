@@ -0,0 +1,112 @@
+//go:build unix
+
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestMoveByCopyKeepsSpecialBits(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_move_copy_mode")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Uma umask restritiva não deve alterar o modo da cópia
+	defer syscall.Umask(syscall.Umask(0077))
+
+	src := filepath.Join(tmpDir, "tool")
+	dst := filepath.Join(tmpDir, "backup", "tool")
+	os.Mkdir(filepath.Dir(dst), 0755)
+	os.WriteFile(src, []byte("#!/bin/sh\n"), 0600)
+
+	mode := os.ModeSetuid | os.ModeSetgid | 0755
+	if err := os.Chmod(src, mode); err != nil {
+		t.Fatalf("Failed to set mode: %v", err)
+	}
+
+	if err := moveByCopy(src, dst); err != nil {
+		t.Fatalf("moveByCopy failed: %v", err)
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatalf("Expected destination to exist: %v", err)
+	}
+	if info.Mode() != mode {
+		t.Errorf("Expected mode %v, got %v", mode, info.Mode())
+	}
+}
+
+func TestRestoreCopyAttributes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_restore_attributes")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	defer syscall.Umask(syscall.Umask(0077))
+
+	kept := filepath.Join(tmpDir, "kept.txt")
+	duplicate := filepath.Join(tmpDir, "duplicate.txt")
+	os.WriteFile(kept, []byte("same content"), 0644)
+	os.WriteFile(duplicate, []byte("same content"), 0600)
+	os.Chmod(kept, 0644)
+
+	mode := os.ModeSetgid | 0751
+	if err := os.Chmod(duplicate, mode); err != nil {
+		t.Fatalf("Failed to set mode: %v", err)
+	}
+
+	manager := newTestManager(t, tmpDir, ActionHardlink)
+	if _, err := manager.applyAction(duplicate, "", kept, "checksum"); err != nil {
+		t.Fatalf("Failed to replace with hard link: %v", err)
+	}
+
+	// O log registra o modo em octal e o dono da duplicata
+	records := readLog(t, manager.logFile)
+	if len(records) != 1 || len(records[0]) != 9 || records[0][6] != "2751" {
+		t.Fatalf("Expected mode 2751 in the log entry, got %v", records)
+	}
+	if records[0][7] != strconv.Itoa(os.Geteuid()) || records[0][8] != strconv.Itoa(os.Getegid()) {
+		t.Errorf("Expected owner %d:%d in the log entry, got %v", os.Geteuid(), os.Getegid(), records[0])
+	}
+
+	attrs, err := ParseFileAttributes(records[0][6], records[0][7], records[0][8])
+	if err != nil {
+		t.Fatalf("ParseFileAttributes failed: %v", err)
+	}
+	if err := RestoreCopy(kept, duplicate, attrs); err != nil {
+		t.Fatalf("Failed to restore copy: %v", err)
+	}
+
+	info, err := os.Stat(duplicate)
+	if err != nil {
+		t.Fatalf("Expected restored file to exist: %v", err)
+	}
+	if info.Mode() != mode {
+		t.Errorf("Expected restored mode %v, got %v", mode, info.Mode())
+	}
+
+	// Sem atributos registrados, a cópia recebe o modo do arquivo mantido
+	if err := manager.replaceWithHardlink(duplicate, kept, "checksum"); err != nil {
+		t.Fatalf("Failed to replace with hard link: %v", err)
+	}
+	if err := RestoreCopy(kept, duplicate, nil); err != nil {
+		t.Fatalf("Failed to restore copy: %v", err)
+	}
+	if info, _ := os.Stat(duplicate); info.Mode() != 0644 {
+		t.Errorf("Expected mode of the kept file 0644, got %v", info.Mode())
+	}
+
+	for _, invalid := range []string{"9999", "17777", "rw-r--r--"} {
+		if _, err := ParseFileAttributes(invalid, "", ""); err == nil {
+			t.Errorf("Expected an error for mode %q", invalid)
+		}
+	}
+}
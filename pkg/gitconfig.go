@@ -0,0 +1,182 @@
+package pkg
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// findRepository procura, a partir de dir e subindo até a raiz do sistema de
+// arquivos, o diretório de trabalho de um repositório git. Retorna o diretório de
+// trabalho e o diretório .git, ou strings vazias fora de um repositório.
+func findRepository(dir string) (top, gitDir string) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return dir, dotGit
+			}
+
+			// Worktrees e submódulos usam um arquivo ".git" com "gitdir: caminho"
+			if target := readGitDirFile(dotGit); target != "" {
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(dir, target)
+				}
+				return dir, target
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// readGitDirFile lê o caminho de um arquivo ".git" no formato "gitdir: caminho"
+func readGitDirFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+}
+
+// globalExcludesFile retorna o arquivo core.excludesFile configurado pelo usuário
+// ou pelo repositório. Sem configuração, o git usa $XDG_CONFIG_HOME/git/ignore.
+func globalExcludesFile(gitDir string) string {
+	configHome := gitConfigHome()
+	home, _ := os.UserHomeDir()
+
+	var configFiles []string
+	if configHome != "" {
+		configFiles = append(configFiles, filepath.Join(configHome, "git", "config"))
+	}
+	if home != "" {
+		configFiles = append(configFiles, filepath.Join(home, ".gitconfig"))
+	}
+	if gitDir != "" {
+		configFiles = append(configFiles, filepath.Join(gitDir, "config"))
+	}
+
+	// Arquivos lidos depois têm precedência
+	excludesFile := ""
+	for _, path := range configFiles {
+		if value, ok := readGitConfigValue(path, "core", "excludesfile"); ok {
+			excludesFile = value
+		}
+	}
+
+	if excludesFile == "" {
+		if configHome == "" {
+			return ""
+		}
+		return filepath.Join(configHome, "git", "ignore")
+	}
+
+	if strings.HasPrefix(excludesFile, "~/") && home != "" {
+		excludesFile = filepath.Join(home, excludesFile[2:])
+	}
+	return excludesFile
+}
+
+// gitConfigHome retorna $XDG_CONFIG_HOME ou, sem ele, ~/.config
+func gitConfigHome() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config")
+	}
+	return ""
+}
+
+// readGitConfigValue lê o último valor de uma chave em uma seção de um arquivo de
+// configuração do git. Seções e chaves não diferenciam maiúsculas de minúsculas.
+func readGitConfigValue(path, section, key string) (string, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	var value string
+	found := false
+	current := ""
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		// Cabeçalho de seção, como [core] ou [remote "origin"]
+		if line[0] == '[' {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				continue
+			}
+			name := strings.TrimSpace(line[1:end])
+			if i := strings.IndexAny(name, " \t"); i >= 0 {
+				name = name[:i]
+			}
+			current = strings.ToLower(name)
+			line = strings.TrimSpace(line[end+1:])
+			if line == "" {
+				continue
+			}
+		}
+
+		if current != section {
+			continue
+		}
+
+		name, rawValue, hasValue := strings.Cut(line, "=")
+		if !hasValue || !strings.EqualFold(strings.TrimSpace(name), key) {
+			continue
+		}
+
+		value = parseGitConfigValue(rawValue)
+		found = true
+	}
+
+	return value, found
+}
+
+// parseGitConfigValue remove aspas, escapes e comentários de um valor
+func parseGitConfigValue(raw string) string {
+	var result strings.Builder
+	inQuotes := false
+
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'n':
+				result.WriteByte('\n')
+			case 't':
+				result.WriteByte('\t')
+			default:
+				result.WriteByte(raw[i])
+			}
+		case c == '"':
+			inQuotes = !inQuotes
+		case (c == '#' || c == ';') && !inQuotes:
+			return strings.TrimSpace(result.String())
+		default:
+			result.WriteByte(c)
+		}
+	}
+
+	return strings.TrimSpace(result.String())
+}
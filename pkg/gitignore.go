@@ -10,49 +10,244 @@ import (
 
 // GitignoreManager gerencia as regras do .gitignore
 type GitignoreManager struct {
-	rules []string
+	rules []ignoreRule
+
+	// Índices das regras agrupados pelo diretório base, na ordem de carga, para
+	// consultar apenas as regras dos diretórios acima de cada caminho
+	byBase map[string][]int
+}
+
+// ignoreRule é uma linha de um arquivo .gitignore já interpretada
+type ignoreRule struct {
+	pattern  string   // linha original, para depuração
+	segments []string // padrão dividido por "/"
+	negate   bool     // "!padrão" inclui de novo o que foi excluído
+	dirOnly  bool     // "padrão/" só corresponde a diretórios
+	anchored bool     // com "/" no início ou no meio, relativo ao diretório do .gitignore
+	base     string   // diretório absoluto ao qual a regra se aplica
+	source   string   // arquivo de origem da regra
+	line     int      // linha da regra no arquivo de origem
 }
 
 // NewGitignoreManager cria uma nova instância do gerenciador de .gitignore
 func NewGitignoreManager() *GitignoreManager {
 	return &GitignoreManager{
-		rules: make([]string, 0),
+		rules:  make([]ignoreRule, 0),
+		byBase: make(map[string][]int),
 	}
 }
 
-// LoadGitignore carrega as regras do arquivo .gitignore
+// LoadGitignore carrega as regras do arquivo .gitignore de um diretório. As
+// regras valem apenas para o conteúdo desse diretório, e as regras carregadas
+// depois têm precedência, como os .gitignore de subdiretórios no git.
 func (g *GitignoreManager) LoadGitignore(rootDir string) error {
-	gitignorePath := filepath.Join(rootDir, ".gitignore")
+	if err := g.loadIgnoreFile(filepath.Join(rootDir, ".gitignore"), rootDir); err != nil {
+		return fmt.Errorf("failed to open .gitignore: %w", err)
+	}
+	return nil
+}
+
+// LoadRedupignore carrega as regras do arquivo .redupignore de um diretório, com
+// a mesma sintaxe e o mesmo escopo do .gitignore
+func (g *GitignoreManager) LoadRedupignore(dir string) error {
+	if err := g.loadIgnoreFile(filepath.Join(dir, ".redupignore"), dir); err != nil {
+		return fmt.Errorf("failed to open .redupignore: %w", err)
+	}
+	return nil
+}
+
+// LoadRepositoryExcludes carrega as regras que o git aplica antes do .gitignore
+// do diretório: quando o diretório está dentro de um repositório, o arquivo
+// core.excludesFile do usuário, o .git/info/exclude e os .gitignore dos
+// diretórios entre a raiz do repositório e o diretório, nessa ordem de precedência.
+func (g *GitignoreManager) LoadRepositoryExcludes(dir string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	top, gitDir := findRepository(absDir)
+	if top == "" {
+		return nil
+	}
+
+	if excludesFile := globalExcludesFile(gitDir); excludesFile != "" {
+		if err := g.loadIgnoreFile(excludesFile, top); err != nil {
+			return fmt.Errorf("failed to open %s: %w", excludesFile, err)
+		}
+	}
+
+	excludePath := filepath.Join(gitDir, "info", "exclude")
+	if err := g.loadIgnoreFile(excludePath, top); err != nil {
+		return fmt.Errorf("failed to open %s: %w", excludePath, err)
+	}
+
+	// .gitignore dos diretórios acima do diretório escaneado, do topo para baixo
+	rel, err := filepath.Rel(top, absDir)
+	if err != nil || rel == "." {
+		return nil
+	}
+
+	current := top
+	for _, component := range strings.Split(rel, string(filepath.Separator)) {
+		if err := g.LoadGitignore(current); err != nil {
+			return err
+		}
+		current = filepath.Join(current, component)
+	}
 
-	file, err := os.Open(gitignorePath)
+	return nil
+}
+
+// loadIgnoreFile carrega as regras de um arquivo no formato do .gitignore,
+// aplicadas ao diretório base. Arquivos inexistentes não são um erro.
+func (g *GitignoreManager) loadIgnoreFile(path, baseDir string) error {
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
 	if err != nil {
-		// Se não existir .gitignore, não é um erro
+		// Se o arquivo não existir, não é um erro
 		if os.IsNotExist(err) {
 			return nil
 		}
-		return fmt.Errorf("failed to open .gitignore: %w", err)
+		return err
 	}
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
+	lineNumber := 0
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		lineNumber++
 
-		// Ignorar linhas vazias e comentários
-		if line == "" || strings.HasPrefix(line, "#") {
+		// Linhas vazias e comentários não geram regras
+		rule, ok := parseIgnoreRule(scanner.Text())
+		if !ok {
 			continue
 		}
 
-		g.rules = append(g.rules, line)
+		rule.base = absBase
+		rule.source = path
+		rule.line = lineNumber
+		g.addRule(rule)
 	}
 
 	return scanner.Err()
 }
 
-// ShouldIgnore verifica se um caminho deve ser ignorado
+// parseIgnoreRule interpreta uma linha no formato do .gitignore. Retorna false
+// para linhas vazias e comentários.
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+
+	// Espaços no fim são ignorados, a menos que escapados com "\"
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{pattern: line}
+
+	// "!" no início inverte a regra; "\!" e "\#" são tratados como literais pelo matcher
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	// Uma barra no início ou no meio prende o padrão ao diretório do .gitignore
+	if strings.HasPrefix(line, "/") {
+		rule.anchored = true
+		line = strings.TrimLeft(line, "/")
+	} else if strings.Contains(line, "/") {
+		rule.anchored = true
+	}
+
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+// matches verifica se o padrão da regra corresponde ao caminho relativo, sem
+// considerar a negação nem os diretórios pais
+func (r ignoreRule) matches(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	components := strings.Split(relPath, "/")
+
+	// Sem barra, o padrão é comparado com o nome em qualquer nível
+	if !r.anchored {
+		return wildmatch(r.segments[0], components[len(components)-1])
+	}
+
+	return matchSegments(r.segments, components)
+}
+
+// matchSegments compara os segmentos do padrão com os componentes do caminho.
+// "**" corresponde a zero ou mais diretórios; no fim do padrão, a tudo que está
+// dentro do diretório.
+func matchSegments(pattern, components []string) bool {
+	if len(pattern) == 0 {
+		return len(components) == 0
+	}
+
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(components) > 0
+		}
+		for i := 0; i <= len(components); i++ {
+			if matchSegments(pattern[1:], components[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(components) == 0 || !wildmatch(pattern[0], components[0]) {
+		return false
+	}
+	return matchSegments(pattern[1:], components[1:])
+}
+
+// ShouldIgnore verifica se um arquivo deve ser ignorado. Como no git, um arquivo
+// dentro de um diretório ignorado não pode ser incluído de novo por uma negação.
 func (g *GitignoreManager) ShouldIgnore(path, rootDir string) bool {
+	return g.isIgnored(path, rootDir, false)
+}
+
+// ShouldIgnoreDir verifica se um diretório deve ser ignorado, permitindo que a
+// varredura não desça nele
+func (g *GitignoreManager) ShouldIgnoreDir(path, rootDir string) bool {
+	return g.isIgnored(path, rootDir, true)
+}
+
+// isIgnored avalia os diretórios pais, a partir da raiz, e depois o próprio caminho
+func (g *GitignoreManager) isIgnored(path, rootDir string, isDir bool) bool {
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
 	// Converter para caminho relativo ao diretório raiz
-	relPath, err := filepath.Rel(rootDir, path)
+	relPath, err := filepath.Rel(absRoot, absPath)
 	if err != nil {
 		// Se não conseguir converter, não ignorar
 		return false
@@ -60,77 +255,105 @@ func (g *GitignoreManager) ShouldIgnore(path, rootDir string) bool {
 
 	// Normalizar separadores de caminho
 	relPath = filepath.ToSlash(relPath)
+	if relPath == "." || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return false
+	}
 
-	for _, rule := range g.rules {
-		if g.matchesRule(relPath, rule) {
+	components := strings.Split(relPath, "/")
+	dir := absRoot
+	for _, component := range components[:len(components)-1] {
+		dir = filepath.Join(dir, component)
+		if g.lastMatch(dir, true) {
 			return true
 		}
 	}
 
-	return false
+	return g.lastMatch(absPath, isDir)
 }
 
-// matchesRule verifica se um caminho corresponde a uma regra do .gitignore
-func (g *GitignoreManager) matchesRule(path, rule string) bool {
-	rule = filepath.ToSlash(rule)
+// lastMatch aplica as regras em ordem; a última que corresponde ao caminho
+// absoluto, entre as que valem para ele, decide
+func (g *GitignoreManager) lastMatch(absPath string, isDir bool) bool {
+	rule := g.lastRule(absPath, isDir)
+	return rule != nil && !rule.negate
+}
 
-	// Se a regra começa e termina com /, é um diretório absoluto na raiz
-	if strings.HasPrefix(rule, "/") && strings.HasSuffix(rule, "/") {
-		dir := strings.Trim(rule, "/")
-		return strings.HasPrefix(path, dir+"/") || path == dir
-	}
+// addRule adiciona uma regra já associada ao seu diretório base
+func (g *GitignoreManager) addRule(rule ignoreRule) {
+	g.byBase[rule.base] = append(g.byBase[rule.base], len(g.rules))
+	g.rules = append(g.rules, rule)
+}
 
-	// Se a regra termina com /, é um diretório
-	if strings.HasSuffix(rule, "/") {
-		rule = rule[:len(rule)-1]
-		return strings.HasPrefix(path, rule+"/") || path == rule
-	}
+// lastRule retorna a última regra que corresponde ao caminho absoluto, ou nil.
+// Só as regras dos diretórios acima do caminho são consultadas, do mais profundo
+// para a raiz: como os arquivos são carregados de cima para baixo, isso equivale
+// a aplicar todas as regras na ordem de carga.
+func (g *GitignoreManager) lastRule(absPath string, isDir bool) *ignoreRule {
+	for dir := filepath.Dir(absPath); ; dir = filepath.Dir(dir) {
+		indexes := g.byBase[dir]
+		for i := len(indexes) - 1; i >= 0; i-- {
+			rule := &g.rules[indexes[i]]
+			relPath, ok := relativeTo(rule.base, absPath)
+			if ok && rule.matches(relPath, isDir) {
+				return rule
+			}
+		}
 
-	// Se a regra começa com /, deve corresponder ao início do caminho
-	if strings.HasPrefix(rule, "/") {
-		prefix := strings.TrimPrefix(rule, "/")
-		return strings.HasPrefix(path, prefix)
+		if parent := filepath.Dir(dir); parent == dir {
+			return nil
+		}
 	}
+}
 
-	// Se a regra contém *, é um padrão wildcard
-	if strings.Contains(rule, "*") {
-		return g.matchesWildcard(path, rule)
+// lastMatchingRule retorna a última regra da lista que vale para o caminho
+// absoluto e corresponde a ele, ou nil
+func lastMatchingRule(rules []ignoreRule, absPath string, isDir bool) *ignoreRule {
+	for i := len(rules) - 1; i >= 0; i-- {
+		relPath, ok := relativeTo(rules[i].base, absPath)
+		if ok && rules[i].matches(relPath, isDir) {
+			return &rules[i]
+		}
 	}
-
-	return path == rule || strings.HasSuffix(path, "/"+rule)
+	return nil
 }
 
-// matchesWildcard verifica se um caminho corresponde a um padrão wildcard
-func (g *GitignoreManager) matchesWildcard(path, pattern string) bool {
-	// Converter padrão simples para regex-like matching
-	// Por exemplo: *.log -> qualquer arquivo que termine com .log
+// relativeTo retorna o caminho relativo a base, com "/" como separador, se o
+// caminho estiver dentro de base
+func relativeTo(base, path string) (string, bool) {
+	prefix := base
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
 
-	// Se o padrão é *.ext
-	if strings.HasPrefix(pattern, "*.") {
-		ext := pattern[1:] // Remove o *
-		return strings.HasSuffix(path, ext)
+	if !strings.HasPrefix(path, prefix) || len(path) == len(prefix) {
+		return "", false
 	}
+	return filepath.ToSlash(path[len(prefix):]), true
+}
 
-	// Se o padrão termina com *
-	if strings.HasSuffix(pattern, "*") {
-		prefix := pattern[:len(pattern)-1]
-		return strings.HasPrefix(path, prefix)
+// matchesRule verifica se um caminho corresponde a uma regra do .gitignore, seja
+// pelo próprio arquivo ou por um de seus diretórios pais. A negação não é considerada.
+func (g *GitignoreManager) matchesRule(path, rule string) bool {
+	parsed, ok := parseIgnoreRule(filepath.ToSlash(rule))
+	if !ok {
+		return false
 	}
 
-	// Se o padrão contém * no meio
-	if strings.Contains(pattern, "*") {
-		parts := strings.Split(pattern, "*")
-		if len(parts) == 2 {
-			prefix := parts[0]
-			suffix := parts[1]
-			return strings.HasPrefix(path, prefix) && strings.HasSuffix(path, suffix)
+	components := strings.Split(path, "/")
+	for i := 1; i < len(components); i++ {
+		if parsed.matches(strings.Join(components[:i], "/"), true) {
+			return true
 		}
 	}
 
-	return false
+	return parsed.matches(path, false)
 }
 
 // GetRules retorna as regras carregadas (para debug)
 func (g *GitignoreManager) GetRules() []string {
-	return g.rules
+	rules := make([]string, len(g.rules))
+	for i, rule := range g.rules {
+		rules[i] = rule.pattern
+	}
+	return rules
 }
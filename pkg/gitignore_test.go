@@ -3,6 +3,7 @@ package pkg
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -155,3 +156,135 @@ func TestMatchesRule(t *testing.T) {
 		t.Error("Expected src/build/app.exe to match /src/build/")
 	}
 }
+
+// newTestGitignore cria um gerenciador com as regras informadas, uma por linha
+func newTestGitignore(content string) *GitignoreManager {
+	manager := NewGitignoreManager()
+	for _, line := range strings.Split(content, "\n") {
+		if rule, ok := parseIgnoreRule(line); ok {
+			rule.base = filepath.FromSlash("/repo")
+			manager.addRule(rule)
+		}
+	}
+	return manager
+}
+
+func TestGitignoreSemantics(t *testing.T) {
+	// Casos baseados nos exemplos da documentação do gitignore
+	tests := []struct {
+		name    string
+		rules   string
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		// Padrões sem barra correspondem em qualquer nível
+		{"name at root", "frotz", "frotz", false, true},
+		{"name in subdirectory", "frotz", "a/b/frotz", false, true},
+		{"extension", "*.log", "logs/app.log", false, true},
+		{"star does not cross slash", "foo/*", "foo/bar/hello.c", false, true}, // via o diretório pai foo/bar
+		{"star matches one level", "foo/*", "foo/test.json", false, true},
+		{"star in name", "hello.*", "dir/hello.txt", false, true},
+		{"star in name without dot", "hello.*", "hello", false, false},
+
+		// Barra final: apenas diretórios
+		{"dir-only matches directory", "frotz/", "a/frotz", true, true},
+		{"dir-only does not match file", "frotz/", "a/frotz", false, false},
+		{"dir-only excludes contents", "frotz/", "a/frotz/file.txt", false, true},
+		{"anchored dir-only", "doc/frotz/", "doc/frotz", true, true},
+		{"anchored dir-only not nested", "doc/frotz/", "a/doc/frotz", true, false},
+
+		// Barra no início ou no meio prende o padrão à raiz
+		{"leading slash root file", "/*.c", "cat-file.c", false, true},
+		{"leading slash not nested", "/*.c", "mozilla-sha1/sha1.c", false, false},
+		{"middle slash anchored", "doc/*.txt", "doc/notes.txt", false, true},
+		{"middle slash not nested", "doc/*.txt", "src/doc/notes.txt", false, false},
+		{"prefix is not a match", "/build", "buildfile", false, false},
+
+		// Asterisco duplo
+		{"leading double star", "**/foo", "foo", false, true},
+		{"leading double star nested", "**/foo", "a/b/foo", false, true},
+		{"leading double star with path", "**/foo/bar", "x/foo/bar", false, true},
+		{"leading double star with path mismatch", "**/foo/bar", "x/foo/baz/bar", false, false},
+		{"trailing double star", "abc/**", "abc/x/y.txt", false, true},
+		{"trailing double star not the dir itself", "abc/**", "abc", true, false},
+		{"middle double star zero dirs", "a/**/b", "a/b", false, true},
+		{"middle double star one dir", "a/**/b", "a/x/b", false, true},
+		{"middle double star many dirs", "a/**/b", "a/x/y/b", false, true},
+		{"double star inside name is a star", "foo**bar", "dir/fooXbar", false, true},
+
+		// Curingas e conjuntos
+		{"question mark", "file?.log", "file1.log", false, true},
+		{"question mark needs one char", "file?.log", "file.log", false, false},
+		{"question mark does not match slash", "a?b", "a/b", false, false},
+		{"character set", "[abc].txt", "b.txt", false, true},
+		{"character set mismatch", "[abc].txt", "d.txt", false, false},
+		{"character range", "report-[0-9].csv", "report-7.csv", false, true},
+		{"negated set", "[!a]*.md", "readme.md", false, true},
+		{"negated set mismatch", "[!a]*.md", "about.md", false, false},
+		{"caret negated set", "[^a]*.md", "about.md", false, false},
+		{"posix class", "v[[:digit:]].bin", "v3.bin", false, true},
+
+		// Escapes e espaços
+		{"escaped hash", "\\#file", "#file", false, true},
+		{"comment", "#file", "#file", false, false},
+		{"escaped bang", "\\!important", "!important", false, true},
+		{"escaped star", "a\\*b", "a*b", false, true},
+		{"escaped star is literal", "a\\*b", "axb", false, false},
+		{"trailing spaces ignored", "foo.txt   ", "foo.txt", false, true},
+		{"escaped trailing space kept", "foo\\ ", "foo ", false, true},
+
+		// Negação: a última regra que corresponde decide
+		{"negation re-includes", "*.html\n!foo.html", "foo.html", false, false},
+		{"negation other files", "*.html\n!foo.html", "bar.html", false, true},
+		{"later rule wins", "!foo.html\n*.html", "foo.html", false, true},
+		{"only foo/bar", "/*\n!/foo\n/foo/*\n!/foo/bar", "foo/bar/file.txt", false, false},
+		{"only foo/bar excludes siblings", "/*\n!/foo\n/foo/*\n!/foo/bar", "foo/baz/file.txt", false, true},
+		{"only foo/bar excludes root files", "/*\n!/foo\n/foo/*\n!/foo/bar", "top.txt", false, true},
+
+		// Não é possível incluir um arquivo cujo diretório pai foi excluído
+		{"excluded parent", "build/\n!build/keep.txt", "build/keep.txt", false, true},
+		{"excluded parent with star", "build/*\n!build/keep.txt", "build/keep.txt", false, false},
+	}
+
+	for _, test := range tests {
+		manager := newTestGitignore(test.rules)
+
+		var ignored bool
+		if test.isDir {
+			ignored = manager.ShouldIgnoreDir(filepath.FromSlash("/repo/"+test.path), "/repo")
+		} else {
+			ignored = manager.ShouldIgnore(filepath.FromSlash("/repo/"+test.path), "/repo")
+		}
+
+		if ignored != test.ignored {
+			t.Errorf("%s: rules %q, path %q: expected ignored=%v, got %v", test.name, test.rules, test.path, test.ignored, ignored)
+		}
+	}
+}
+
+func TestWildmatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*", "anything", true},
+		{"*.go", "main.go", true},
+		{"*.go", "main.go.bak", false},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+		{"[a-c]x", "bx", true},
+		{"[]]", "]", true},
+		{"[a-]", "-", true},
+		{"[abc", "[abc", true},
+		{"ação", "ação", true},
+		{"?ção", "ação", true},
+	}
+
+	for _, test := range tests {
+		if got := wildmatch(test.pattern, test.name); got != test.match {
+			t.Errorf("wildmatch(%q, %q): expected %v, got %v", test.pattern, test.name, test.match, got)
+		}
+	}
+}
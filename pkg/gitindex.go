@@ -0,0 +1,273 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GitTrackedMode define o tratamento dos arquivos registrados no índice do git
+type GitTrackedMode string
+
+const (
+	// GitTrackedAllow trata arquivos rastreados como qualquer outro arquivo
+	GitTrackedAllow GitTrackedMode = ""
+	// GitTrackedSkip deixa os arquivos rastreados fora da varredura
+	GitTrackedSkip GitTrackedMode = "skip"
+	// GitTrackedProtect marca os arquivos rastreados como protegidos, de modo que
+	// sejam sempre a cópia mantida e nunca sejam alterados
+	GitTrackedProtect GitTrackedMode = "protect"
+)
+
+// ParseGitTrackedMode converte o nome de um modo; o nome vazio desativa o modo
+func ParseGitTrackedMode(name string) (GitTrackedMode, error) {
+	switch mode := GitTrackedMode(name); mode {
+	case GitTrackedAllow, GitTrackedSkip, GitTrackedProtect:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported git tracked mode '%s' (supported: skip, protect)", name)
+	}
+}
+
+// gitIndex contém os caminhos registrados no índice de um repositório, relativos
+// ao diretório de trabalho e com "/" como separador
+type gitIndex struct {
+	files map[string]bool
+
+	// Diretórios inteiros registrados como uma única entrada em um índice
+	// esparso (sparse index), com "/" no fim
+	dirs []string
+}
+
+// contains verifica se o caminho relativo está registrado no índice
+func (idx *gitIndex) contains(relPath string) bool {
+	if idx.files[relPath] {
+		return true
+	}
+	for _, dir := range idx.dirs {
+		if strings.HasPrefix(relPath, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// readGitIndex lê o arquivo de índice de um diretório .git. Um repositório sem
+// índice, ainda sem nenhum commit ou arquivo adicionado, não rastreia nada.
+func readGitIndex(gitDir string) (*gitIndex, error) {
+	path := filepath.Join(gitDir, "index")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &gitIndex{files: make(map[string]bool)}, nil
+		}
+		return nil, err
+	}
+
+	// Repositórios SHA-256 usam ids de objeto de 32 bytes
+	hashSize := 20
+	if format, ok := readGitConfigValue(filepath.Join(gitDir, "config"), "extensions", "objectformat"); ok && strings.EqualFold(format, "sha256") {
+		hashSize = 32
+	}
+
+	index, err := parseGitIndex(data, hashSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git index %s: %w", path, err)
+	}
+	return index, nil
+}
+
+// parseGitIndex interpreta o conteúdo de um índice do git nas versões 2, 3 e 4.
+// Cada entrada tem 40 bytes de metadados (ctime, mtime, dev, ino, mode, uid,
+// gid e tamanho), o id do objeto, 2 bytes de flags, 2 bytes de flags estendidas
+// quando indicado (a partir da versão 3) e o caminho. Nas versões 2 e 3 o caminho
+// termina com NULs que alinham a entrada em 8 bytes; na versão 4 ele é
+// comprimido em relação ao caminho da entrada anterior.
+func parseGitIndex(data []byte, hashSize int) (*gitIndex, error) {
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, fmt.Errorf("not a git index file")
+	}
+
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported git index version %d", version)
+	}
+	count := binary.BigEndian.Uint32(data[8:12])
+
+	const (
+		statSize     = 40
+		flagExtended = 0x4000
+	)
+
+	index := &gitIndex{files: make(map[string]bool)}
+	pos := 12
+	previous := ""
+
+	for i := uint32(0); i < count; i++ {
+		start := pos
+		if pos+statSize+hashSize+2 > len(data) {
+			return nil, fmt.Errorf("truncated entry %d", i)
+		}
+		pos += statSize + hashSize
+
+		flags := binary.BigEndian.Uint16(data[pos:])
+		pos += 2
+		if flags&flagExtended != 0 {
+			if version < 3 {
+				return nil, fmt.Errorf("extended flags in version %d entry %d", version, i)
+			}
+			pos += 2
+		}
+		if pos > len(data) {
+			return nil, fmt.Errorf("truncated entry %d", i)
+		}
+
+		var name string
+		if version == 4 {
+			// Quantos bytes remover do fim do caminho anterior, seguido do sufixo
+			strip, n := decodeIndexVarint(data[pos:])
+			if n == 0 || strip > len(previous) {
+				return nil, fmt.Errorf("invalid path compression in entry %d", i)
+			}
+			pos += n
+
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("truncated entry %d", i)
+			}
+			name = previous[:len(previous)-strip] + string(data[pos:pos+end])
+			pos += end + 1
+		} else {
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("truncated entry %d", i)
+			}
+			name = string(data[pos : pos+end])
+
+			// De 1 a 8 NULs completam a entrada até um múltiplo de 8 bytes
+			pos = start + (pos+end-start+8)&^7
+		}
+		previous = name
+
+		if strings.HasSuffix(name, "/") {
+			index.dirs = append(index.dirs, name)
+		} else {
+			index.files[name] = true
+		}
+	}
+
+	// As entradas de um índice dividido (split index) ficam em outro arquivo
+	for pos+8 <= len(data)-hashSize {
+		signature := string(data[pos : pos+4])
+		size := int(binary.BigEndian.Uint32(data[pos+4:]))
+		if signature == "link" {
+			return nil, fmt.Errorf("split index is not supported")
+		}
+		pos += 8 + size
+	}
+
+	return index, nil
+}
+
+// decodeIndexVarint decodifica um inteiro no formato de tamanho variável do git,
+// retornando o valor e o número de bytes lidos, ou 0 bytes se estiver truncado
+func decodeIndexVarint(buf []byte) (int, int) {
+	if len(buf) == 0 {
+		return 0, 0
+	}
+
+	c := buf[0]
+	value := int(c & 127)
+	n := 1
+	for c&128 != 0 {
+		if n >= len(buf) {
+			return 0, 0
+		}
+		c = buf[n]
+		n++
+		value = (value+1)<<7 + int(c&127)
+	}
+	return value, n
+}
+
+// trackedRepository é o repositório que contém um diretório, com o seu índice
+type trackedRepository struct {
+	top   string
+	index *gitIndex
+}
+
+// SetGitTracked define o tratamento dos arquivos rastreados pelo git, lidos
+// diretamente do índice do repositório que contém cada arquivo
+func (s *Scanner) SetGitTracked(mode GitTrackedMode) {
+	s.gitTracked = mode
+}
+
+// applyGitTracked descarta ou protege os arquivos rastreados pelo git, conforme
+// o modo configurado. Arquivos não rastreados e ignorados não são alterados.
+func (s *Scanner) applyGitTracked(files []FileInfo) ([]FileInfo, error) {
+	if s.gitTracked == GitTrackedAllow {
+		return files, nil
+	}
+
+	var result []FileInfo
+	for _, file := range files {
+		tracked, err := s.isTracked(file.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if tracked {
+			if s.gitTracked == GitTrackedSkip {
+				continue
+			}
+			file.Protected = true
+		}
+		result = append(result, file)
+	}
+	return result, nil
+}
+
+// isTracked verifica se o arquivo está registrado no índice do repositório que o
+// contém. Os repositórios são procurados uma vez por diretório em cada varredura.
+func (s *Scanner) isTracked(path string) (bool, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+
+	dir := filepath.Dir(absPath)
+	repo, found := s.repositories[dir]
+	if !found {
+		repo = &trackedRepository{}
+		if top, gitDir := findRepository(dir); top != "" {
+			repo.top = top
+			if repo.index, err = s.loadGitIndex(gitDir); err != nil {
+				return false, err
+			}
+		}
+		s.repositories[dir] = repo
+	}
+
+	if repo.index == nil {
+		return false, nil
+	}
+	relPath, ok := relativeTo(repo.top, absPath)
+	return ok && repo.index.contains(relPath), nil
+}
+
+// loadGitIndex lê o índice de um diretório .git uma única vez por varredura
+func (s *Scanner) loadGitIndex(gitDir string) (*gitIndex, error) {
+	if index, found := s.indexes[gitDir]; found {
+		return index, nil
+	}
+
+	index, err := readGitIndex(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	s.indexes[gitDir] = index
+	return index, nil
+}
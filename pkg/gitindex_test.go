@@ -0,0 +1,182 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildGitIndex monta um índice do git com as entradas informadas. Na versão 3,
+// as entradas terminadas em "+" recebem flags estendidas; na versão 4, os
+// caminhos são comprimidos em relação ao anterior.
+func buildGitIndex(version uint32, names []string) []byte {
+	data := []byte("DIRC")
+	data = binary.BigEndian.AppendUint32(data, version)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(names)))
+
+	previous := ""
+	for _, name := range names {
+		extended := strings.HasSuffix(name, "+")
+		name = strings.TrimSuffix(name, "+")
+
+		start := len(data)
+		data = append(data, make([]byte, 40+20)...)
+
+		flags := uint16(len(name))
+		if extended {
+			flags |= 0x4000
+		}
+		data = binary.BigEndian.AppendUint16(data, flags)
+		if extended {
+			data = binary.BigEndian.AppendUint16(data, 0x2000)
+		}
+
+		if version == 4 {
+			common := 0
+			for common < len(name) && common < len(previous) && name[common] == previous[common] {
+				common++
+			}
+			data = appendIndexVarint(data, len(previous)-common)
+			data = append(data, name[common:]...)
+			data = append(data, 0)
+		} else {
+			data = append(data, name...)
+			for padding := 8 - (len(data)-start)%8; padding > 0; padding-- {
+				data = append(data, 0)
+			}
+		}
+		previous = name
+	}
+
+	// Extensão TREE vazia e checksum final
+	data = append(data, "TREE"...)
+	data = binary.BigEndian.AppendUint32(data, 0)
+	return append(data, make([]byte, 20)...)
+}
+
+// appendIndexVarint codifica um inteiro no formato de tamanho variável do git
+func appendIndexVarint(data []byte, value int) []byte {
+	buf := []byte{byte(value & 127)}
+	for value >>= 7; value > 0; value >>= 7 {
+		value--
+		buf = append([]byte{byte(128 | value&127)}, buf...)
+	}
+	return append(data, buf...)
+}
+
+func TestParseGitIndex(t *testing.T) {
+	// Remover mais de 127 bytes do caminho anterior exige um varint de dois bytes
+	long := strings.Repeat("d", 130)
+	names := []string{"README.md", "src/main.go", "src/main_test.go+", "src/pkg/util.go", long + "/a.txt", long + "/b.txt", "z.txt"}
+
+	expected := []string{"README.md", "src/main.go", "src/main_test.go", "src/pkg/util.go", long + "/a.txt", long + "/b.txt", "z.txt"}
+
+	for _, version := range []uint32{2, 3, 4} {
+		entries := names
+		if version == 2 {
+			entries = expected
+		}
+
+		index, err := parseGitIndex(buildGitIndex(version, entries), 20)
+		if err != nil {
+			t.Fatalf("parseGitIndex failed (version %d): %v", version, err)
+		}
+
+		if len(index.files) != len(expected) {
+			t.Errorf("Expected %d files (version %d), got %v", len(expected), version, index.files)
+		}
+		for _, name := range expected {
+			if !index.contains(name) {
+				t.Errorf("Expected %s in the index (version %d), got %v", name, version, index.files)
+			}
+		}
+	}
+
+	// Entradas de diretório de um índice esparso cobrem todo o seu conteúdo
+	index, err := parseGitIndex(buildGitIndex(4, []string{"docs/", "src/main.go"}), 20)
+	if err != nil {
+		t.Fatalf("parseGitIndex failed: %v", err)
+	}
+	if !index.contains("docs/guide/intro.md") || index.contains("docsite/index.md") {
+		t.Errorf("Expected sparse directory docs/ to cover only its contents, got %v", index.dirs)
+	}
+
+	for name, data := range map[string][]byte{
+		"invalid signature":   []byte("DIRX\x00\x00\x00\x02\x00\x00\x00\x00"),
+		"unsupported version": buildGitIndex(5, nil),
+		"truncated entry":     buildGitIndex(2, []string{"file.txt"})[:40],
+	} {
+		if _, err := parseGitIndex(data, 20); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
+
+func TestScannerGitTracked(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_scanner_git_tracked")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Isolar a configuração do usuário
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "home", ".config"))
+
+	repo := filepath.Join(tmpDir, "repo")
+	os.MkdirAll(filepath.Join(repo, ".git"), 0755)
+	os.MkdirAll(filepath.Join(repo, "src"), 0755)
+	os.WriteFile(filepath.Join(repo, "src", "tracked.txt"), []byte("abc"), 0644)
+	os.WriteFile(filepath.Join(repo, "src", "untracked.txt"), []byte("abc"), 0644)
+	os.WriteFile(filepath.Join(repo, ".git", "index"), buildGitIndex(4, []string{"src/tracked.txt"}), 0644)
+
+	tests := []struct {
+		mode      GitTrackedMode
+		files     int
+		protected string
+	}{
+		{mode: GitTrackedAllow, files: 2},
+		{mode: GitTrackedSkip, files: 1},
+		{mode: GitTrackedProtect, files: 2, protected: "tracked.txt"},
+	}
+
+	for _, tt := range tests {
+		scanner := NewScanner(0)
+		scanner.SetGitTracked(tt.mode)
+
+		files, err := scanner.ScanDirectory(filepath.Join(repo, "src"))
+		if err != nil {
+			t.Fatalf("ScanDirectory failed (mode %q): %v", tt.mode, err)
+		}
+
+		if len(files) != tt.files {
+			t.Errorf("Expected %d files (mode %q), got %v", tt.files, tt.mode, files)
+		}
+		for _, file := range files {
+			if file.Protected != (filepath.Base(file.Path) == tt.protected) {
+				t.Errorf("Unexpected protection for %s (mode %q): %v", file.Path, tt.mode, file.Protected)
+			}
+			if tt.mode == GitTrackedSkip && filepath.Base(file.Path) == "tracked.txt" {
+				t.Errorf("Expected tracked.txt to be skipped, got %v", files)
+			}
+		}
+	}
+
+	// CheckIgnore informa os arquivos rastreados que a varredura deixaria de fora
+	scanner := NewScanner(0)
+	scanner.SetGitTracked(GitTrackedSkip)
+	paths := []string{filepath.Join(repo, "src", "tracked.txt"), filepath.Join(repo, "src", "untracked.txt")}
+	matches, err := scanner.CheckIgnore(repo, paths)
+	if err != nil {
+		t.Fatalf("CheckIgnore failed: %v", err)
+	}
+	if !matches[0].Tracked || !matches[0].Excluded || matches[1].Tracked || matches[1].Excluded {
+		t.Errorf("Expected only tracked.txt to be excluded as tracked, got %+v", matches)
+	}
+
+	if _, err := ParseGitTrackedMode("ignore"); err == nil {
+		t.Error("Expected an error for an unknown git tracked mode")
+	}
+}
@@ -1,17 +1,62 @@
 package pkg
 
 import (
+	"context"
 	"crypto/md5"
+	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/zeebo/blake3"
+	"github.com/zeebo/xxh3"
 )
 
+// hashAlgorithms registra os construtores de hash disponíveis, indexados pelo
+// nome aceito na flag --checksum
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"blake3": func() hash.Hash { return blake3.New() },
+	"xxh64":  func() hash.Hash { return xxhash.New() },
+	"xxh3":   func() hash.Hash { return xxh3.New() },
+}
+
+// RegisterHashAlgorithm registra um algoritmo de hash adicional. Deve ser chamada
+// durante a inicialização, antes de qualquer Hasher ser usado.
+func RegisterHashAlgorithm(name string, constructor func() hash.Hash) {
+	hashAlgorithms[name] = constructor
+}
+
+// IsSupportedAlgorithm verifica se um algoritmo de hash está registrado
+func IsSupportedAlgorithm(name string) bool {
+	_, exists := hashAlgorithms[name]
+	return exists
+}
+
+// SupportedAlgorithms retorna os nomes dos algoritmos registrados em ordem alfabética
+func SupportedAlgorithms() []string {
+	names := make([]string, 0, len(hashAlgorithms))
+	for name := range hashAlgorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Hasher é responsável por calcular checksums de arquivos
 type Hasher struct {
 	algorithm string
+	cache     *ChecksumCache
 }
 
 // NewHasher cria uma nova instância do hasher
@@ -21,31 +66,114 @@ func NewHasher(algorithm string) *Hasher {
 	}
 }
 
+// SetCache define o cache persistente consultado antes de calcular checksums
+func (h *Hasher) SetCache(cache *ChecksumCache) {
+	h.cache = cache
+}
+
+// newHash cria o hash correspondente ao algoritmo configurado
+func (h *Hasher) newHash() (hash.Hash, error) {
+	constructor, exists := hashAlgorithms[h.algorithm]
+	if !exists {
+		return nil, fmt.Errorf("unsupported algorithm: %s", h.algorithm)
+	}
+	return constructor(), nil
+}
+
 // CalculateChecksum calcula o checksum de um arquivo
 func (h *Hasher) CalculateChecksum(filePath string) (string, error) {
+	return h.CalculateChecksumContext(context.Background(), filePath)
+}
+
+// CalculateChecksumContext calcula o checksum de um arquivo, interrompendo a
+// leitura quando o contexto é cancelado
+func (h *Hasher) CalculateChecksumContext(ctx context.Context, filePath string) (string, error) {
+	hash, err := h.newHash()
+	if err != nil {
+		return "", err
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open file %s: %w", filePath, err)
 	}
 	defer file.Close()
 
-	var hash interface{}
+	// Consultar o cache antes de ler o conteúdo do arquivo
+	var info os.FileInfo
+	var absPath string
+	if h.cache != nil {
+		if info, err = file.Stat(); err != nil {
+			return "", fmt.Errorf("failed to stat file %s: %w", filePath, err)
+		}
+		if absPath, err = filepath.Abs(filePath); err != nil {
+			return "", fmt.Errorf("failed to get absolute path for %s: %w", filePath, err)
+		}
+		if checksum, found := h.cache.Lookup(h.algorithm, absPath, info); found {
+			return checksum, nil
+		}
+	}
+
+	_, err = io.Copy(hash, &contextReader{ctx: ctx, reader: file})
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if h.cache != nil {
+		h.cache.Store(h.algorithm, absPath, info, checksum)
+	}
+
+	return checksum, nil
+}
 
-	switch h.algorithm {
-	case "sha256":
-		hash = sha256.New()
-	case "md5":
-		hash = md5.New()
-	default:
-		return "", fmt.Errorf("unsupported algorithm: %s", h.algorithm)
+// CalculatePartialChecksum calcula o checksum apenas do início e do fim de um
+// arquivo, lendo no máximo blockSize bytes de cada extremidade
+func (h *Hasher) CalculatePartialChecksum(filePath string, blockSize int64) (string, error) {
+	hash, err := h.newHash()
+	if err != nil {
+		return "", err
 	}
 
-	_, err = io.Copy(hash.(io.Writer), file)
+	file, err := os.Open(filePath)
 	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file %s: %w", filePath, err)
+	}
+	size := info.Size()
+
+	// Bloco inicial
+	headSize := min(blockSize, size)
+	if _, err := io.Copy(hash, io.NewSectionReader(file, 0, headSize)); err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+
+	// Bloco final, sem repetir bytes já lidos no bloco inicial
+	tailStart := max(headSize, size-blockSize)
+	if _, err := io.Copy(hash, io.NewSectionReader(file, tailStart, size-tailStart)); err != nil {
 		return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
 	}
 
-	return hex.EncodeToString(hash.(io.Writer).(interface{ Sum([]byte) []byte }).Sum(nil)), nil
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// contextReader interrompe a leitura quando o contexto é cancelado
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+// Read lê do leitor subjacente se o contexto ainda estiver ativo
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
 }
 
 // GetAlgorithm retorna o algoritmo atual
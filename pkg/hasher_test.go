@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -101,3 +102,111 @@ func TestGetAlgorithm(t *testing.T) {
 		t.Errorf("Expected algorithm '%s', got '%s'", algorithm, hasher.GetAlgorithm())
 	}
 }
+
+func TestCalculatePartialChecksum(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_partial")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Arquivos com início e fim iguais, diferindo apenas no meio
+	middleA := make([]byte, 64)
+	middleB := make([]byte, 64)
+	middleB[10] = 1
+	head := []byte("0123456789abcdef")
+	tail := []byte("fedcba9876543210")
+
+	fileA := filepath.Join(tmpDir, "a.bin")
+	fileB := filepath.Join(tmpDir, "b.bin")
+	os.WriteFile(fileA, append(append(append([]byte{}, head...), middleA...), tail...), 0644)
+	os.WriteFile(fileB, append(append(append([]byte{}, head...), middleB...), tail...), 0644)
+
+	hasher := NewHasher("sha256")
+
+	partialA, err := hasher.CalculatePartialChecksum(fileA, 16)
+	if err != nil {
+		t.Fatalf("Failed to calculate partial checksum: %v", err)
+	}
+	partialB, err := hasher.CalculatePartialChecksum(fileB, 16)
+	if err != nil {
+		t.Fatalf("Failed to calculate partial checksum: %v", err)
+	}
+
+	if partialA != partialB {
+		t.Error("Expected equal partial checksums for files with same head and tail")
+	}
+
+	fullA, _ := hasher.CalculateChecksum(fileA)
+	fullB, _ := hasher.CalculateChecksum(fileB)
+	if fullA == fullB {
+		t.Error("Expected different full checksums for files with different content")
+	}
+
+	// Com blocos maiores que o arquivo, o hash parcial cobre o arquivo inteiro
+	partialWhole, err := hasher.CalculatePartialChecksum(fileA, 1024)
+	if err != nil {
+		t.Fatalf("Failed to calculate partial checksum: %v", err)
+	}
+	if partialWhole != fullA {
+		t.Error("Expected partial checksum of whole file to match full checksum")
+	}
+}
+
+func TestCalculateChecksumAlgorithms(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test_algorithms")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if _, err := tmpFile.WriteString("test content for every algorithm"); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	// Tamanho esperado do checksum em caracteres hexadecimais
+	tests := []struct {
+		algorithm string
+		length    int
+	}{
+		{"md5", 32},
+		{"sha1", 40},
+		{"sha256", 64},
+		{"sha512", 128},
+		{"blake3", 64},
+		{"xxh64", 16},
+		{"xxh3", 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			if !IsSupportedAlgorithm(tt.algorithm) {
+				t.Fatalf("Expected %s to be supported", tt.algorithm)
+			}
+
+			checksum, err := NewHasher(tt.algorithm).CalculateChecksum(tmpFile.Name())
+			if err != nil {
+				t.Fatalf("Failed to calculate %s checksum: %v", tt.algorithm, err)
+			}
+
+			if len(checksum) != tt.length {
+				t.Errorf("Expected %s checksum length %d, got %d", tt.algorithm, tt.length, len(checksum))
+			}
+		})
+	}
+}
+
+func TestSupportedAlgorithms(t *testing.T) {
+	if IsSupportedAlgorithm("crc32") {
+		t.Error("Expected crc32 NOT to be supported")
+	}
+
+	algorithms := SupportedAlgorithms()
+	for i := 1; i < len(algorithms); i++ {
+		if algorithms[i-1] > algorithms[i] {
+			t.Errorf("Expected sorted algorithms, got %v", algorithms)
+			break
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreMatcher combina as fontes de exclusão de uma raiz de varredura. Em ordem
+// de precedência: padrões de --exclude, padrões de --include, arquivos
+// .redupignore e as regras do git (.gitignore, .git/info/exclude e
+// core.excludesFile).
+type ignoreMatcher struct {
+	root     string // raiz em caminho absoluto
+	rootDir  string // raiz como informada
+	excludes []ignoreRule
+	includes []ignoreRule
+	redup    *GitignoreManager
+	git      *GitignoreManager // nil quando o .gitignore está desativado
+
+	// Diretórios cujos arquivos de exclusão já foram carregados por loadParents
+	loaded map[string]bool
+}
+
+// parsePatterns interpreta padrões informados na linha de comando, com a mesma
+// sintaxe do .gitignore. A negação não é aceita: --include cumpre esse papel.
+func parsePatterns(patterns []string, source string) ([]ignoreRule, error) {
+	var rules []ignoreRule
+	for _, pattern := range patterns {
+		rule, ok := parseIgnoreRule(filepath.ToSlash(pattern))
+		if !ok {
+			continue
+		}
+		if rule.negate {
+			return nil, fmt.Errorf("invalid %s pattern %q: negation is not supported", source, pattern)
+		}
+		rule.source = source
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// newIgnoreMatcher cria o matcher de uma raiz, carregando as regras do
+// repositório e os arquivos de exclusão da própria raiz. Os arquivos dos
+// subdiretórios são carregados com loadDir durante a varredura.
+func newIgnoreMatcher(root string, excludes, includes []ignoreRule, useGitignore bool) (*ignoreMatcher, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ignoreMatcher{
+		root:     absRoot,
+		rootDir:  root,
+		excludes: withBase(excludes, absRoot),
+		includes: withBase(includes, absRoot),
+		redup:    NewGitignoreManager(),
+		loaded:   map[string]bool{filepath.Clean(root): true},
+	}
+
+	if useGitignore {
+		m.git = NewGitignoreManager()
+		if err := m.git.LoadRepositoryExcludes(root); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.loadDir(root); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// withBase copia as regras aplicando-as ao diretório base
+func withBase(rules []ignoreRule, base string) []ignoreRule {
+	result := make([]ignoreRule, len(rules))
+	for i, rule := range rules {
+		rule.base = base
+		result[i] = rule
+	}
+	return result
+}
+
+// loadDir carrega o .gitignore e o .redupignore de um diretório
+func (m *ignoreMatcher) loadDir(dir string) error {
+	if m.git != nil {
+		if err := m.git.LoadGitignore(dir); err != nil {
+			return err
+		}
+	}
+	return m.redup.LoadRedupignore(dir)
+}
+
+// loadParents carrega os arquivos de exclusão dos diretórios entre a raiz e um
+// caminho avulso, fora de uma varredura, uma única vez por diretório. Retorna
+// false quando o caminho não está dentro da raiz.
+func (m *ignoreMatcher) loadParents(absPath string) (bool, error) {
+	relPath, ok := relativeTo(m.root, absPath)
+	if !ok {
+		return false, nil
+	}
+
+	components := strings.Split(relPath, "/")
+	dir := m.rootDir
+	for _, component := range components[:len(components)-1] {
+		dir = filepath.Join(dir, component)
+		if m.loaded[dir] {
+			continue
+		}
+		m.loaded[dir] = true
+		if err := m.loadDir(dir); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// gitignore retorna as regras do git, ou um gerenciador vazio quando desativadas
+func (m *ignoreMatcher) gitignore() *GitignoreManager {
+	if m.git == nil {
+		return NewGitignoreManager()
+	}
+	return m.git
+}
+
+// shouldSkipFile verifica se um arquivo deve ficar fora da varredura
+func (m *ignoreMatcher) shouldSkipFile(path string) bool {
+	ignored, _ := m.match(path, false)
+	return ignored
+}
+
+// shouldSkipDir verifica se a varredura pode deixar de descer em um diretório.
+// Um diretório excluído pelos arquivos de exclusão ainda é percorrido quando um
+// padrão de --include pode corresponder a algo dentro dele.
+func (m *ignoreMatcher) shouldSkipDir(path string) bool {
+	ignored, rule := m.match(path, true)
+	if !ignored {
+		return false
+	}
+	if rule != nil && rule.source == excludeSource {
+		return true
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return true
+	}
+	relPath, ok := relativeTo(m.root, absPath)
+	if !ok {
+		return true
+	}
+
+	for _, include := range m.includes {
+		if include.couldMatchWithin(relPath) {
+			return false
+		}
+	}
+	return true
+}
+
+// Origens das regras informadas na linha de comando
+const (
+	excludeSource = "--exclude"
+	includeSource = "--include"
+)
+
+// match decide se um caminho é excluído e retorna a regra que decidiu, ou nil
+// quando nenhuma regra corresponde. Os padrões de --exclude e --include valem
+// para o caminho e todo o seu conteúdo; nos arquivos de exclusão, como no git, o
+// conteúdo de um diretório excluído não pode ser incluído de novo.
+func (m *ignoreMatcher) match(path string, isDir bool) (bool, *ignoreRule) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, nil
+	}
+	relPath, ok := relativeTo(m.root, absPath)
+	if !ok {
+		return false, nil
+	}
+
+	// Diretórios pais, a partir da raiz, e depois o próprio caminho
+	components := strings.Split(relPath, "/")
+	paths := make([]string, len(components))
+	dir := m.root
+	for i, component := range components {
+		dir = filepath.Join(dir, component)
+		paths[i] = dir
+	}
+	last := len(paths) - 1
+
+	for _, rules := range [][]ignoreRule{m.excludes, m.includes} {
+		for i, p := range paths {
+			if rule := lastMatchingRule(rules, p, i < last || isDir); rule != nil {
+				return rule.source == excludeSource, rule
+			}
+		}
+	}
+
+	for i, p := range paths {
+		ignored, rule := m.matchFiles(p, i < last || isDir)
+		if ignored || i == last {
+			return ignored, rule
+		}
+	}
+	return false, nil
+}
+
+// matchFiles aplica as regras dos arquivos de exclusão a um único caminho: o
+// .redupignore tem precedência sobre as regras do git
+func (m *ignoreMatcher) matchFiles(absPath string, isDir bool) (bool, *ignoreRule) {
+	if rule := m.redup.lastRule(absPath, isDir); rule != nil {
+		return !rule.negate, rule
+	}
+	if m.git != nil {
+		if rule := m.git.lastRule(absPath, isDir); rule != nil {
+			return !rule.negate, rule
+		}
+	}
+	return false, nil
+}
+
+// couldMatchWithin verifica se o padrão pode corresponder ao diretório relativo
+// ou a algum caminho dentro dele
+func (r ignoreRule) couldMatchWithin(relDir string) bool {
+	// Sem barra, o padrão pode corresponder a um nome em qualquer nível
+	if !r.anchored {
+		return true
+	}
+	return matchPrefix(r.segments, strings.Split(relDir, "/"))
+}
+
+// matchPrefix verifica se os componentes do diretório podem ser o início de um
+// caminho que corresponde aos segmentos do padrão
+func matchPrefix(pattern, components []string) bool {
+	if len(components) == 0 || len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		return true
+	}
+	if !wildmatch(pattern[0], components[0]) {
+		return false
+	}
+	return matchPrefix(pattern[1:], components[1:])
+}
+
+// IgnoreMatch descreve por que um caminho é ou não excluído da varredura
+type IgnoreMatch struct {
+	Path     string
+	Excluded bool
+
+	// Regra que decidiu: arquivo de origem relativo à raiz (ou --exclude/--include),
+	// linha e padrão. Source fica vazio quando nenhuma regra corresponde ao caminho.
+	Source  string
+	Line    int
+	Pattern string
+
+	// Tracked indica que o arquivo está no índice do git; com SetGitTracked, ele é
+	// então excluído (GitTrackedSkip) ou protegido (GitTrackedProtect)
+	Tracked bool
+
+	// Err indica que o caminho não pôde ser avaliado, por estar fora da raiz
+	Err error
+}
+
+// CheckIgnore informa, para cada caminho dentro da raiz, se ele seria excluído
+// de uma varredura da raiz e qual regra decidiu, como o git check-ignore -v.
+// Caminhos relativos são relativos à raiz, e caminhos inexistentes são
+// avaliados como arquivos. Um caminho fora da raiz recebe o erro em Err, sem
+// interromper a avaliação dos demais.
+func (s *Scanner) CheckIgnore(root string, paths []string) ([]IgnoreMatch, error) {
+	s.resetScan()
+
+	matcher, err := newIgnoreMatcher(root, s.excludes, s.includes, s.useGitignore)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []IgnoreMatch
+
+	for _, path := range paths {
+		absPath := path
+		if !filepath.IsAbs(path) {
+			absPath = filepath.Join(matcher.root, path)
+		}
+
+		// Carregar os arquivos de exclusão dos diretórios entre a raiz e o caminho
+		inside, err := matcher.loadParents(absPath)
+		if err != nil {
+			return matches, err
+		}
+		if !inside {
+			matches = append(matches, IgnoreMatch{Path: path, Err: fmt.Errorf("path is outside '%s'", root)})
+			continue
+		}
+
+		info, err := os.Stat(absPath)
+		isDir := err == nil && info.IsDir()
+
+		match := IgnoreMatch{Path: path}
+		excluded, rule := matcher.match(absPath, isDir)
+		match.Excluded = excluded
+		if rule != nil {
+			match.Source = matcher.relativeSource(rule.source)
+			match.Line = rule.line
+			match.Pattern = rule.pattern
+		}
+
+		// Arquivos rastreados são tratados depois das regras de exclusão
+		if !excluded && !isDir && s.gitTracked != GitTrackedAllow {
+			if match.Tracked, err = s.isTracked(absPath); err != nil {
+				return matches, err
+			}
+			match.Excluded = match.Tracked && s.gitTracked == GitTrackedSkip
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
+// relativeSource retorna o arquivo de origem de uma regra relativo à raiz, como o
+// git check-ignore -v. As flags --exclude e --include ficam como estão.
+func (m *ignoreMatcher) relativeSource(source string) string {
+	if source == excludeSource || source == includeSource {
+		return source
+	}
+
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return source
+	}
+	if rel, err := filepath.Rel(m.root, absSource); err == nil {
+		return rel
+	}
+	return source
+}
@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckIgnore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_check_ignore")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Isolar a configuração do usuário
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "home", ".config"))
+
+	repo := filepath.Join(tmpDir, "repo")
+	files := map[string]string{
+		".gitignore":        "# build outputs\nbuild/\n*.log\n",
+		"src/.redupignore":  "!keep.log\n",
+		"src/keep.log":      "x",
+		"src/main.go":       "x",
+		"build/out/app.o":   "x",
+		"data/cache.sqlite": "x",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	os.MkdirAll(filepath.Join(repo, ".git", "info"), 0755)
+
+	scanner := NewScanner(0)
+	if err := scanner.SetExcludePatterns([]string{"*.sqlite"}); err != nil {
+		t.Fatalf("SetExcludePatterns failed: %v", err)
+	}
+
+	paths := []string{"build/out/app.o", "src/keep.log", "other.log", "data/cache.sqlite", "src/main.go", "build"}
+	for i, path := range paths {
+		paths[i] = filepath.Join(repo, filepath.FromSlash(path))
+	}
+
+	matches, err := scanner.CheckIgnore(repo, paths)
+	if err != nil {
+		t.Fatalf("CheckIgnore failed: %v", err)
+	}
+
+	expected := []IgnoreMatch{
+		{Excluded: true, Source: ".gitignore", Line: 2, Pattern: "build/"},
+		{Excluded: false, Source: filepath.Join("src", ".redupignore"), Line: 1, Pattern: "!keep.log"},
+		{Excluded: true, Source: ".gitignore", Line: 3, Pattern: "*.log"},
+		{Excluded: true, Source: "--exclude", Pattern: "*.sqlite"},
+		{Excluded: false},
+		{Excluded: true, Source: ".gitignore", Line: 2, Pattern: "build/"},
+	}
+	if len(matches) != len(expected) {
+		t.Fatalf("Expected %d matches, got %v", len(expected), matches)
+	}
+	for i, match := range matches {
+		expected[i].Path = paths[i]
+		if match != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], match)
+		}
+	}
+
+	// Caminhos relativos são relativos à raiz, não ao diretório de trabalho
+	matches, err = scanner.CheckIgnore(repo, []string{"other.log", filepath.Join("src", "keep.log")})
+	if err != nil {
+		t.Fatalf("CheckIgnore failed for relative paths: %v", err)
+	}
+	if !matches[0].Excluded || matches[0].Pattern != "*.log" || matches[1].Excluded {
+		t.Errorf("Expected other.log excluded and src/keep.log included, got %+v", matches)
+	}
+
+	// Um caminho fora da raiz é um erro apenas dele, sem interromper os demais
+	matches, err = scanner.CheckIgnore(filepath.Join(repo, "src"), []string{filepath.Join(repo, "other.log"), "keep.log"})
+	if err != nil {
+		t.Fatalf("CheckIgnore failed: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Err == nil || matches[1].Err != nil || matches[1].Source != ".redupignore" {
+		t.Errorf("Expected an error only for the path outside the root, got %+v", matches)
+	}
+
+	// As regras de .git/info/exclude também são relativas à raiz
+	os.WriteFile(filepath.Join(repo, ".git", "info", "exclude"), []byte("*.tmp\n"), 0644)
+	matches, err = scanner.CheckIgnore(repo, []string{"scratch.tmp"})
+	if err != nil {
+		t.Fatalf("CheckIgnore failed: %v", err)
+	}
+	if source := filepath.Join(".git", "info", "exclude"); matches[0].Source != source {
+		t.Errorf("Expected source %s, got %+v", source, matches[0])
+	}
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// KeepStrategy compara dois arquivos de um grupo. Um valor negativo indica que a
+// deve ser preferido a b como o arquivo mantido, positivo o contrário e zero empate.
+type KeepStrategy func(a, b FileInfo) int
+
+// KeepPolicy é uma sequência de estratégias em que cada uma desempata a anterior
+type KeepPolicy []KeepStrategy
+
+// keepStrategies registra as estratégias disponíveis na flag --keep
+var keepStrategies = map[string]KeepStrategy{
+	"oldest": func(a, b FileInfo) int {
+		return a.ModTime.Compare(b.ModTime)
+	},
+	"newest": func(a, b FileInfo) int {
+		return b.ModTime.Compare(a.ModTime)
+	},
+	"shortest-path": func(a, b FileInfo) int {
+		return cmp.Compare(len(a.Path), len(b.Path))
+	},
+	"longest-path": func(a, b FileInfo) int {
+		return cmp.Compare(len(b.Path), len(a.Path))
+	},
+	"shallowest": func(a, b FileInfo) int {
+		return cmp.Compare(pathDepth(a.Path), pathDepth(b.Path))
+	},
+	"deepest": func(a, b FileInfo) int {
+		return cmp.Compare(pathDepth(b.Path), pathDepth(a.Path))
+	},
+	"alphabetical": func(a, b FileInfo) int {
+		return strings.Compare(a.Path, b.Path)
+	},
+	"most-hard-links": func(a, b FileInfo) int {
+		return cmp.Compare(b.Links, a.Links)
+	},
+}
+
+// ParseKeepPolicy converte uma lista de estratégias separadas por vírgula, como
+// "oldest,shortest-path", rejeitando nomes desconhecidos
+func ParseKeepPolicy(spec string) (KeepPolicy, error) {
+	var policy KeepPolicy
+
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		strategy, exists := keepStrategies[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown keep strategy '%s' (supported: %s)", name, strings.Join(KeepStrategyNames(), ", "))
+		}
+		policy = append(policy, strategy)
+	}
+
+	return policy, nil
+}
+
+// KeepStrategyNames retorna os nomes das estratégias em ordem alfabética
+func KeepStrategyNames() []string {
+	names := make([]string, 0, len(keepStrategies))
+	for name := range keepStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compare aplica as estratégias em sequência até encontrar uma que desempate
+func (p KeepPolicy) Compare(a, b FileInfo) int {
+	for _, strategy := range p {
+		if result := strategy(a, b); result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+// ApplyKeepPolicy reordena os arquivos de cada grupo para que o arquivo a ser
+// mantido fique na primeira posição. Arquivos protegidos vêm sempre antes dos
+// demais, independentemente da política. Empates preservam a ordem atual.
+func ApplyKeepPolicy(groups []FileGroup, policy KeepPolicy) {
+	for _, group := range groups {
+		files := group.Files
+		sort.SliceStable(files, func(i, j int) bool {
+			if files[i].Protected != files[j].Protected {
+				return files[i].Protected
+			}
+			return policy.Compare(files[i], files[j]) < 0
+		})
+	}
+}
+
+// pathDepth retorna o número de diretórios no caminho
+func pathDepth(path string) int {
+	return strings.Count(filepath.ToSlash(filepath.Clean(path)), "/")
+}
@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseKeepPolicy(t *testing.T) {
+	policy, err := ParseKeepPolicy("oldest, shortest-path")
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	if len(policy) != 2 {
+		t.Errorf("Expected 2 strategies, got %d", len(policy))
+	}
+
+	policy, err = ParseKeepPolicy("")
+	if err != nil || len(policy) != 0 {
+		t.Errorf("Expected empty policy, got %d strategies (err: %v)", len(policy), err)
+	}
+
+	if _, err := ParseKeepPolicy("oldest,biggest"); err == nil {
+		t.Error("Expected error for unknown strategy")
+	}
+}
+
+func TestApplyKeepPolicy(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	files := []FileInfo{
+		{Path: "/data/b/deep/file.txt", ModTime: base, Links: 1},
+		{Path: "/data/a.txt", ModTime: base.Add(time.Hour), Links: 3},
+		{Path: "/data/z/file.txt", ModTime: base, Links: 1},
+	}
+
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"oldest", "/data/b/deep/file.txt"},
+		{"newest", "/data/a.txt"},
+		{"oldest,shortest-path", "/data/z/file.txt"},
+		{"shallowest", "/data/a.txt"},
+		{"deepest", "/data/b/deep/file.txt"},
+		{"longest-path", "/data/b/deep/file.txt"},
+		{"alphabetical", "/data/a.txt"},
+		{"most-hard-links", "/data/a.txt"},
+		{"", "/data/b/deep/file.txt"},
+	}
+
+	for _, test := range tests {
+		group := FileGroup{Files: append([]FileInfo{}, files...)}
+		policy, err := ParseKeepPolicy(test.spec)
+		if err != nil {
+			t.Fatalf("Failed to parse policy %q: %v", test.spec, err)
+		}
+
+		ApplyKeepPolicy([]FileGroup{group}, policy)
+		if group.Files[0].Path != test.expected {
+			t.Errorf("Policy %q: expected %s to be kept, got %s", test.spec, test.expected, group.Files[0].Path)
+		}
+	}
+}
@@ -19,11 +19,42 @@ type Menu struct {
 
 // NewMenu cria uma nova instância do menu
 func NewMenu(config *Config) *Menu {
+	hasher := NewDeduplicatorHasher(config.Checksum)
+	hasher.SetPartialBytes(config.PartialBytes)
+	hasher.SetJobs(config.Jobs)
+	hasher.SetCollectErrors(config.KeepGoing)
+
+	backupMgr := NewManager(config.BackupDir, config.Yes)
+	backupMgr.SetVerify(config.Verify)
+	if action, err := ParseAction(config.Action); err == nil {
+		backupMgr.SetAction(action)
+	}
+	backupMgr.SetDeleteConfirmed(config.Yes && config.IKnow)
+	if style, err := ParseSymlinkStyle(config.SymlinkStyle); err == nil {
+		backupMgr.SetSymlinkStyle(style)
+	}
+
+	scanner := NewScanner(config.MinSize)
+	scanner.SetFollowSymlinks(config.FollowSymlinks)
+	if err := scanner.SetProtectedDirs(config.ProtectedDirs); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if err := scanner.SetExcludePatterns(config.Excludes); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if err := scanner.SetIncludePatterns(config.Includes); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	scanner.SetUseGitignore(!config.NoGitignore)
+	if mode, err := ParseGitTrackedMode(config.GitTracked); err == nil {
+		scanner.SetGitTracked(mode)
+	}
+
 	return &Menu{
 		config:    config,
-		scanner:   NewScanner(config.MinSize),
-		hasher:    NewDeduplicatorHasher(config.Checksum),
-		backupMgr: NewManager(config.BackupDir, config.Yes),
+		scanner:   scanner,
+		hasher:    hasher,
+		backupMgr: backupMgr,
 	}
 }
 
@@ -103,13 +134,17 @@ func (m *Menu) scanDirectory() {
 		return
 	}
 
-	fileGroups, err := m.hasher.GroupByChecksum(files)
+	m.duplicates, err = m.hasher.FindDuplicates(files)
 	if err != nil {
 		fmt.Printf("Error calculating checksums: %v\n", err)
 		return
 	}
 
-	m.duplicates = FilterDuplicates(fileGroups)
+	PrintHashErrors(m.hasher.Errors())
+
+	policy, _ := ParseKeepPolicy(m.config.Keep)
+	ApplyKeepPolicy(m.duplicates, policy)
+
 	fmt.Printf("Found %d duplicate groups.\n", len(m.duplicates))
 }
 
@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// stdinLines recebe as linhas lidas do stdin por uma única goroutine, para que
+// os prompts possam ser interrompidos pelo contexto sem perder entrada
+var (
+	stdinOnce  sync.Once
+	stdinLines chan string
+)
+
+// readLine lê uma linha do stdin, retornando o erro do contexto se ele for
+// cancelado antes de o usuário responder e io.EOF quando o stdin termina
+func readLine(ctx context.Context) (string, error) {
+	stdinOnce.Do(func() {
+		stdinLines = make(chan string)
+		go func() {
+			reader := bufio.NewReader(os.Stdin)
+			for {
+				line, err := reader.ReadString('\n')
+				if line != "" {
+					stdinLines <- line
+				}
+				if err != nil {
+					close(stdinLines)
+					return
+				}
+			}
+		}()
+	})
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case line, ok := <-stdinLines:
+		if !ok {
+			return "", io.EOF
+		}
+		return line, nil
+	}
+}
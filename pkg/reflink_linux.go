@@ -0,0 +1,121 @@
+//go:build linux
+
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// fideduperange é o número do ioctl FIDEDUPERANGE
+// (_IOWR(0x94, 54, struct file_dedupe_range))
+const fideduperange = 0xC0189436
+
+// Resultado de cada destino do FIDEDUPERANGE; valores negativos são -errno
+const fileDedupeRangeDiffers = 1
+
+// fileDedupeRange espelha struct file_dedupe_range com um único destino
+type fileDedupeRange struct {
+	srcOffset uint64
+	srcLength uint64
+	destCount uint16
+	reserved1 uint16
+	reserved2 uint32
+
+	// struct file_dedupe_range_info
+	destFd       int64
+	destOffset   uint64
+	bytesDeduped uint64
+	status       int32
+	reserved     uint32
+}
+
+// reflinkReplace faz path compartilhar as extensões de source sem trocar o
+// arquivo: o inode, os hard links, os atributos estendidos, as ACLs e o
+// diretório pai ficam como estavam. O kernel confere atomicamente que os dois
+// conteúdos são idênticos antes de compartilhar cada trecho.
+func reflinkReplace(source, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ErrReflinkUnsupported
+	}
+
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// O destino é aberto para escrita, sem truncar: os tamanhos já são iguais
+	dst, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if err := dedupeFile(src, dst, info.Size()); err != nil {
+		return err
+	}
+
+	// O conteúdo não muda, mas alguns sistemas de arquivos atualizam o mtime
+	after, err := dst.Stat()
+	if err != nil {
+		return err
+	}
+	if !after.ModTime().Equal(info.ModTime()) {
+		atime := time.Unix(stat.Atim.Unix())
+		return os.Chtimes(path, atime, info.ModTime())
+	}
+	return nil
+}
+
+// dedupeFile compartilha as extensões de src com dst através do ioctl
+// FIDEDUPERANGE, em tantas chamadas quantas o kernel exigir
+func dedupeFile(src, dst *os.File, size int64) error {
+	for offset := uint64(0); offset < uint64(size); {
+		arg := fileDedupeRange{
+			srcOffset:  offset,
+			srcLength:  uint64(size) - offset,
+			destCount:  1,
+			destFd:     int64(dst.Fd()),
+			destOffset: offset,
+		}
+
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, src.Fd(), fideduperange, uintptr(unsafe.Pointer(&arg)))
+		if errno != 0 {
+			return dedupeError(errno)
+		}
+
+		switch {
+		case arg.status == fileDedupeRangeDiffers:
+			return fmt.Errorf("%s changed: contents differ from %s", dst.Name(), src.Name())
+		case arg.status < 0:
+			return dedupeError(syscall.Errno(-arg.status))
+		case arg.bytesDeduped == 0:
+			return fmt.Errorf("%w: no bytes deduplicated at offset %d", ErrReflinkUnsupported, offset)
+		}
+
+		offset += arg.bytesDeduped
+	}
+	return nil
+}
+
+// dedupeError classifica o erro do FIDEDUPERANGE, separando a falta de suporte
+// do sistema de arquivos dos demais erros
+func dedupeError(errno syscall.Errno) error {
+	unsupported := []syscall.Errno{syscall.EOPNOTSUPP, syscall.ENOTTY, syscall.EINVAL, syscall.EXDEV, syscall.ENOSYS}
+	for _, e := range unsupported {
+		if errors.Is(errno, e) {
+			return fmt.Errorf("%w: %v", ErrReflinkUnsupported, errno)
+		}
+	}
+	return errno
+}
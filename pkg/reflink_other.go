@@ -0,0 +1,8 @@
+//go:build !linux
+
+package pkg
+
+// reflinkReplace não é suportado fora do Linux
+func reflinkReplace(source, path string) error {
+	return ErrReflinkUnsupported
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 )
 
@@ -14,23 +15,31 @@ func PrintSummary(groups []FileGroup) {
 		return
 	}
 
-	// Contar total de arquivos duplicados (excluindo o original de cada grupo)
+	// Contar as duplicatas que podem ser liberadas, como no espaço total: hard
+	// links contam uma vez por inode e o inode mantido não conta
 	totalDuplicates := 0
 	for _, group := range groups {
-		totalDuplicates += len(group.Files) - 1
+		totalDuplicates += reclaimableInodes(group.Files)
 	}
 
 	fmt.Printf("Found %d duplicate files:\n\n", totalDuplicates)
 
+	// Com várias raízes, indicar de qual raiz cada arquivo veio
+	showRoot := len(distinctGroupRoots(groups)) > 1
+
 	// Mostrar cada grupo de duplicatas
 	for i, group := range groups {
 		if len(group.Files) > 1 {
-			fmt.Printf("[%d] %s\n", i+1, group.Files[0].Path)
+			links := hardLinkTargets(group.Files)
+			fmt.Printf("[%d] %s\n", i+1, fileLabel(group.Files[0], showRoot, links[0]))
 			fmt.Printf("Found %d copies:\n", len(group.Files)-1)
 
 			// Mostrar todas as cópias (excluindo o primeiro arquivo que é considerado o original)
 			for j := 1; j < len(group.Files); j++ {
-				fmt.Printf("  %s\n", group.Files[j].Path)
+				fmt.Printf("  %s\n", fileLabel(group.Files[j], showRoot, links[j]))
+			}
+			if allProtected(group.Files) {
+				fmt.Println("All files are in reference directories and will not be changed.")
 			}
 			fmt.Println()
 		}
@@ -41,39 +50,175 @@ func PrintSummary(groups []FileGroup) {
 	fmt.Printf("Total space that can be freed: %s\n", formatBytes(totalSize))
 }
 
-// ExportJSON exporta os resultados em formato JSON
-func ExportJSON(groups []FileGroup, writer io.Writer) error {
-	type FileInfo struct {
-		Path string `json:"path"`
-		Size int64  `json:"size"`
+// fileLabel retorna o caminho exibido no resumo, com a raiz de origem, a marcação
+// de arquivos protegidos e o arquivo do qual ele é hard link, se houver
+func fileLabel(file FileInfo, showRoot bool, linkTarget string) string {
+	label := file.Path
+	if showRoot && file.Root != "" {
+		label += fmt.Sprintf(" [root: %s]", file.Root)
 	}
-
-	type GroupInfo struct {
-		Checksum string     `json:"checksum"`
-		Size     int64      `json:"size"`
-		Files    []FileInfo `json:"files"`
+	if file.Protected {
+		label += " (protected)"
+	}
+	if linkTarget != "" {
+		label += fmt.Sprintf(" (hard link of %s)", linkTarget)
 	}
+	return label
+}
 
-	var jsonGroups []GroupInfo
+// distinctGroupRoots retorna as raízes distintas presentes nos grupos
+func distinctGroupRoots(groups []FileGroup) map[string]bool {
+	roots := make(map[string]bool)
 	for _, group := range groups {
-		var files []FileInfo
 		for _, file := range group.Files {
-			files = append(files, FileInfo{
-				Path: file.Path,
-				Size: file.Size,
-			})
+			roots[file.Root] = true
 		}
+	}
+	return roots
+}
+
+// PrintPipelineStats exibe quantos arquivos foram eliminados em cada etapa do pipeline
+func PrintPipelineStats(stats PipelineStats) {
+	fmt.Println("Pipeline stages:")
+	fmt.Printf("  Files scanned:                 %d\n", stats.Scanned)
+	fmt.Printf("  Eliminated by size:            %d\n", stats.Scanned-stats.SizeMatched)
+	fmt.Printf("  Eliminated by partial hash:    %d\n", stats.SizeMatched-stats.PartialMatched)
+	fmt.Printf("  Eliminated by full checksum:   %d\n", stats.PartialMatched-stats.Duplicates)
+	fmt.Printf("  Files in duplicate groups:     %d\n", stats.Duplicates)
+	fmt.Println()
+}
+
+// PrintSymlinkStats exibe quantos links simbólicos foram encontrados. Links
+// simbólicos não são analisados como duplicatas nem alterados.
+func PrintSymlinkStats(symlinks []string) {
+	if len(symlinks) == 0 {
+		return
+	}
+
+	fmt.Printf("Symlinks skipped:                %d (symlinks are never treated as duplicates)\n\n", len(symlinks))
+}
 
-		jsonGroups = append(jsonGroups, GroupInfo{
+// PrintSymlinkLoops exibe os links simbólicos não seguidos por levarem a um
+// diretório já percorrido
+func PrintSymlinkLoops(loops []string) {
+	for _, path := range loops {
+		fmt.Fprintf(os.Stderr, "Warning: not following %s: directory already scanned (symlink loop or duplicate path)\n", path)
+	}
+}
+
+// PrintCacheStats exibe o uso do cache de checksums
+func PrintCacheStats(stats CacheStats) {
+	fmt.Printf("Checksum cache: %d hits, %d misses, %d entries\n\n", stats.Hits, stats.Misses, stats.Entries)
+}
+
+// PrintHashErrors exibe os arquivos que não puderam ser lidos durante o cálculo dos checksums
+func PrintHashErrors(errs []error) {
+	if len(errs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: %d files could not be hashed and were skipped:\n", len(errs))
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "  %v\n", err)
+	}
+}
+
+// PrintScanErrors exibe os caminhos da lista de entrada que não puderam ser lidos
+func PrintScanErrors(errs []error) {
+	if len(errs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: %d listed paths could not be read and were skipped:\n", len(errs))
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "  %v\n", err)
+	}
+}
+
+// jsonFile é a representação JSON de um arquivo
+type jsonFile struct {
+	Path       string `json:"path"`
+	Root       string `json:"root,omitempty"`
+	Size       int64  `json:"size"`
+	Protected  bool   `json:"protected,omitempty"`
+	HardLinkOf string `json:"hard_link_of,omitempty"`
+}
+
+// jsonGroup é a representação JSON de um grupo de duplicatas
+type jsonGroup struct {
+	Checksum string     `json:"checksum"`
+	Size     int64      `json:"size"`
+	Files    []jsonFile `json:"files"`
+}
+
+// ExportJSON exporta os resultados em formato JSON
+func ExportJSON(groups []FileGroup, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(toJSONGroups(groups))
+}
+
+// ExportCompareJSON exporta o resultado de uma comparação em formato JSON, com os
+// grupos encontrados dos dois lados e os arquivos da origem sem cópia
+func ExportCompareJSON(groups []FileGroup, missing []FileInfo, writer io.Writer) error {
+	result := struct {
+		Matches []jsonGroup `json:"matches"`
+		Missing []jsonFile  `json:"missing"`
+	}{
+		Matches: toJSONGroups(groups),
+		Missing: toJSONFiles(missing),
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// toJSONGroups converte os grupos para a representação JSON
+func toJSONGroups(groups []FileGroup) []jsonGroup {
+	var jsonGroups []jsonGroup
+	for _, group := range groups {
+		jsonGroups = append(jsonGroups, jsonGroup{
 			Checksum: group.Checksum,
 			Size:     group.Size,
-			Files:    files,
+			Files:    toJSONFiles(group.Files),
 		})
+
+		// Marcar os arquivos que são hard links de outro arquivo do grupo
+		files := jsonGroups[len(jsonGroups)-1].Files
+		for i, target := range hardLinkTargets(group.Files) {
+			files[i].HardLinkOf = target
+		}
 	}
+	return jsonGroups
+}
 
-	encoder := json.NewEncoder(writer)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(jsonGroups)
+// toJSONFiles converte os arquivos para a representação JSON
+func toJSONFiles(files []FileInfo) []jsonFile {
+	var jsonFiles []jsonFile
+	for _, file := range files {
+		jsonFiles = append(jsonFiles, jsonFile{
+			Path:      file.Path,
+			Root:      file.Root,
+			Size:      file.Size,
+			Protected: file.Protected,
+		})
+	}
+	return jsonFiles
+}
+
+// PrintMissingFiles exibe os arquivos da origem que não têm cópia nos diretórios comparados
+func PrintMissingFiles(files []FileInfo) {
+	if len(files) == 0 {
+		fmt.Println("Every source file has a copy in the compared directories.")
+		return
+	}
+
+	fmt.Printf("%d source files have no copy in the compared directories:\n", len(files))
+	for _, file := range files {
+		fmt.Printf("  %s\n", file.Path)
+	}
+	fmt.Println()
 }
 
 // ExportCSV exporta os resultados em formato CSV
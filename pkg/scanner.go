@@ -1,8 +1,14 @@
 package pkg
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -11,6 +17,16 @@ type FileInfo struct {
 	Path    string
 	Size    int64
 	ModTime time.Time
+	Dev     uint64
+	Inode   uint64
+	Links   uint64
+
+	// Root é a raiz de varredura em que o arquivo foi encontrado
+	Root string
+
+	// Protected indica que o arquivo está em um diretório de referência e nunca
+	// deve ser alterado
+	Protected bool
 }
 
 // Scanner é responsável por escanear diretórios e encontrar arquivos
@@ -18,6 +34,38 @@ type Scanner struct {
 	minSize      int64
 	gitignoreMgr *GitignoreManager
 	rootDir      string
+
+	// Diretórios de referência como informados, em caminhos absolutos e com os
+	// links simbólicos resolvidos
+	protectedRoots []string
+	protectedDirs  []string
+	protectedReal  []string
+
+	// Separador da lista de caminhos lida do stdin ('\n' ou '\x00')
+	separator byte
+
+	// Caminhos da lista que não puderam ser lidos
+	errors []error
+
+	// Links simbólicos para diretórios são seguidos quando ativado
+	followSymlinks bool
+
+	// Links simbólicos encontrados e não seguidos, links que levariam a um
+	// diretório já percorrido e diretórios já percorridos na varredura atual
+	symlinks []string
+	loops    []string
+	visited  map[fileKey]bool
+
+	// Padrões de --exclude e --include e uso das regras do git
+	excludes     []ignoreRule
+	includes     []ignoreRule
+	useGitignore bool
+
+	// Tratamento dos arquivos rastreados pelo git e repositórios e índices já
+	// lidos na varredura atual
+	gitTracked   GitTrackedMode
+	repositories map[string]*trackedRepository
+	indexes      map[string]*gitIndex
 }
 
 // NewScanner cria uma nova instância do scanner
@@ -25,68 +73,605 @@ func NewScanner(minSize int64) *Scanner {
 	return &Scanner{
 		minSize:      minSize,
 		gitignoreMgr: NewGitignoreManager(),
+		separator:    '\n',
+		useGitignore: true,
+	}
+}
+
+// SetProtectedDirs define diretórios de referência. Seus arquivos são escaneados e
+// participam dos grupos, mas são marcados como protegidos.
+func (s *Scanner) SetProtectedDirs(dirs []string) error {
+	s.protectedRoots, s.protectedDirs, s.protectedReal = nil, nil, nil
+	for _, dir := range dirs {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		s.protectedRoots = append(s.protectedRoots, dir)
+		s.protectedDirs = append(s.protectedDirs, absDir)
+		s.protectedReal = append(s.protectedReal, realPath(absDir))
+	}
+	return nil
+}
+
+// SetFollowSymlinks define se links simbólicos para diretórios devem ser seguidos.
+// Links para arquivos nunca são tratados como arquivos.
+func (s *Scanner) SetFollowSymlinks(follow bool) {
+	s.followSymlinks = follow
+}
+
+// Symlinks retorna os links simbólicos encontrados na última varredura, que não
+// são analisados nem alterados
+func (s *Scanner) Symlinks() []string {
+	return s.symlinks
+}
+
+// SymlinkLoops retorna os links simbólicos não seguidos por levarem a um diretório
+// já percorrido, seja por formarem um ciclo ou por repetirem outro caminho
+func (s *Scanner) SymlinkLoops() []string {
+	return s.loops
+}
+
+// SetExcludePatterns define padrões, na sintaxe do .gitignore, de caminhos que
+// nunca entram na varredura, mesmo que correspondam a um padrão de inclusão
+func (s *Scanner) SetExcludePatterns(patterns []string) error {
+	rules, err := parsePatterns(patterns, excludeSource)
+	if err != nil {
+		return err
+	}
+	s.excludes = rules
+	return nil
+}
+
+// SetIncludePatterns define padrões, na sintaxe do .gitignore, de caminhos que
+// entram na varredura mesmo quando excluídos pelo .redupignore ou pelo .gitignore
+func (s *Scanner) SetIncludePatterns(patterns []string) error {
+	rules, err := parsePatterns(patterns, includeSource)
+	if err != nil {
+		return err
 	}
+	s.includes = rules
+	return nil
+}
+
+// SetUseGitignore define se as regras do .gitignore, do .git/info/exclude e do
+// core.excludesFile são aplicadas. O .redupignore é sempre aplicado.
+func (s *Scanner) SetUseGitignore(use bool) {
+	s.useGitignore = use
+}
+
+// resetScan descarta os resultados da varredura anterior
+func (s *Scanner) resetScan() {
+	s.errors = nil
+	s.symlinks = nil
+	s.loops = nil
+	s.visited = make(map[fileKey]bool)
+	s.repositories = make(map[string]*trackedRepository)
+	s.indexes = make(map[string]*gitIndex)
 }
 
 // ScanDirectory escaneia recursivamente um diretório e retorna informações dos arquivos
 func (s *Scanner) ScanDirectory(root string) ([]FileInfo, error) {
-	s.rootDir = root
+	return s.ScanDirectoryContext(context.Background(), root)
+}
 
-	// Carregar regras do .gitignore
-	if err := s.gitignoreMgr.LoadGitignore(root); err != nil {
+// ScanDirectoryContext escaneia um diretório como ScanDirectory, interrompendo a
+// varredura quando o contexto é cancelado
+func (s *Scanner) ScanDirectoryContext(ctx context.Context, root string) ([]FileInfo, error) {
+	return s.ScanDirectoriesContext(ctx, []string{root})
+}
+
+// ScanDirectories escaneia várias raízes em uma única lista de arquivos
+func (s *Scanner) ScanDirectories(roots []string) ([]FileInfo, error) {
+	return s.ScanDirectoriesContext(context.Background(), roots)
+}
+
+// ScanDirectoriesContext escaneia várias raízes, cada uma com suas próprias regras
+// de exclusão. Raízes repetidas ou contidas em outra raiz são escaneadas uma
+// única vez, assim como os diretórios de referência, e cada arquivo é marcado
+// com a raiz em que foi encontrado.
+func (s *Scanner) ScanDirectoriesContext(ctx context.Context, roots []string) ([]FileInfo, error) {
+	s.resetScan()
+	files, err := s.scanRoots(ctx, roots)
+	if err != nil {
+		return files, err
+	}
+	return s.applyGitTracked(files)
+}
+
+// scanRoots escaneia as raízes e os diretórios de referência sem descartar os
+// resultados já acumulados na varredura atual
+func (s *Scanner) scanRoots(ctx context.Context, roots []string) ([]FileInfo, error) {
+	candidates := append(append([]string{}, roots...), s.protectedRoots...)
+
+	scanRoots, err := distinctRoots(candidates)
+	if err != nil {
 		return nil, err
 	}
 
 	var files []FileInfo
+	seen := make(map[string]bool)
 
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	for i, root := range scanRoots {
+		// Carregar as regras do repositório e os arquivos de exclusão da raiz,
+		// descartando os de varreduras anteriores; os dos subdiretórios são
+		// carregados durante a varredura
+		matcher, err := newIgnoreMatcher(root, s.excludes, s.includes, s.useGitignore)
 		if err != nil {
-			return err
+			return files, err
+		}
+		if i == 0 {
+			s.rootDir = root
+			s.gitignoreMgr = matcher.gitignore()
+		}
+
+		rootFiles, err := s.walk(ctx, root, matcher)
+
+		// Um mesmo arquivo nunca é contado duas vezes
+		for _, file := range rootFiles {
+			absPath, absErr := filepath.Abs(file.Path)
+			if absErr != nil {
+				absPath = file.Path
+			}
+			if seen[absPath] {
+				continue
+			}
+			seen[absPath] = true
+			files = append(files, file)
+		}
+
+		if err != nil {
+			return files, err
 		}
+	}
 
-		// Ignorar o diretório .git
-		if info.IsDir() && info.Name() == ".git" {
-			return filepath.SkipDir
+	return files, nil
+}
+
+// distinctRoots remove raízes repetidas e raízes contidas em outras, preservando
+// a ordem em que foram informadas
+func distinctRoots(roots []string) ([]string, error) {
+	absRoots := make([]string, len(roots))
+	for i, root := range roots {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
 		}
+		absRoots[i] = absRoot
+	}
 
-		// Pular diretórios
-		if info.IsDir() {
-			return nil
+	var result []string
+	for i, root := range roots {
+		covered := false
+		for j, other := range absRoots {
+			if i == j {
+				continue
+			}
+			// Raízes iguais ficam na primeira ocorrência; raízes aninhadas, na mais externa
+			if other == absRoots[i] {
+				covered = j < i
+			} else {
+				covered = IsWithin(absRoots[i], other)
+			}
+			if covered {
+				break
+			}
 		}
 
-		// Verificar se o arquivo deve ser ignorado pelo .gitignore
-		if s.gitignoreMgr.ShouldIgnore(path, s.rootDir) {
-			return nil
+		if !covered {
+			result = append(result, root)
+		}
+	}
+
+	return result, nil
+}
+
+// walk percorre um diretório aplicando as regras de exclusão dessa raiz. Links
+// simbólicos nunca entram na lista de arquivos: links para diretórios só são
+// seguidos com SetFollowSymlinks e os demais são registrados em Symlinks. Cada
+// diretório, identificado por dispositivo e inode, é percorrido uma única vez, o
+// que evita ciclos de links simbólicos.
+func (s *Scanner) walk(ctx context.Context, root string, matcher *ignoreMatcher) ([]FileInfo, error) {
+	var files []FileInfo
+
+	// A raiz é sempre seguida, mesmo quando é um link simbólico
+	rootInfo, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// addFile aplica os filtros a um arquivo regular e o adiciona à lista
+	addFile := func(path string, info os.FileInfo) {
+		// Ignorar dispositivos, pipes e sockets
+		if !info.Mode().IsRegular() {
+			return
+		}
+
+		// Verificar se o arquivo foi excluído pelas regras de exclusão
+		if matcher.shouldSkipFile(path) {
+			return
 		}
 
 		// Verificar tamanho mínimo
 		if s.minSize > 0 && info.Size() < s.minSize {
-			return nil
+			return
 		}
 
 		// Ignorar arquivos vazios (0 bytes)
 		if info.Size() == 0 {
-			return nil
+			return
 		}
 
 		// Adicionar arquivo à lista
-		files = append(files, FileInfo{
-			Path:    path,
-			Size:    info.Size(),
-			ModTime: info.ModTime(),
-		})
+		files = append(files, s.newFileInfo(path, root, info))
+	}
+
+	var walkDir func(dir string, info os.FileInfo) error
+	walkDir = func(dir string, info os.FileInfo) error {
+		s.visited[dirKey(dir, info)] = true
+
+		// O .gitignore e o .redupignore de um subdiretório valem apenas dentro dele
+		if dir != root {
+			if err := matcher.loadDir(dir); err != nil {
+				return err
+			}
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			// Interromper se o contexto foi cancelado
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			info, err := os.Lstat(path)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case info.Mode()&os.ModeSymlink != 0:
+				target, err := os.Stat(path)
+				if err != nil || !target.IsDir() || !s.followSymlinks {
+					s.symlinks = append(s.symlinks, path)
+					continue
+				}
+
+				if matcher.shouldSkipDir(path) {
+					continue
+				}
+
+				// Diretório já percorrido: ciclo ou outro caminho para o mesmo lugar
+				if s.visited[dirKey(path, target)] {
+					s.loops = append(s.loops, path)
+					continue
+				}
+
+				if err := walkDir(path, target); err != nil {
+					return err
+				}
+			case info.IsDir():
+				// Ignorar o diretório .git, diretórios já percorridos e
+				// diretórios excluídos pelas regras de exclusão
+				if info.Name() == ".git" || s.visited[dirKey(path, info)] {
+					continue
+				}
+				if matcher.shouldSkipDir(path) {
+					continue
+				}
+
+				if err := walkDir(path, info); err != nil {
+					return err
+				}
+			default:
+				addFile(path, info)
+			}
+		}
 
 		return nil
-	})
+	}
+
+	if !rootInfo.IsDir() {
+		addFile(root, rootInfo)
+		return files, nil
+	}
 
-	return files, err
+	if s.visited[dirKey(root, rootInfo)] {
+		return nil, nil
+	}
+
+	return files, walkDir(root, rootInfo)
+}
+
+// dirKey identifica um diretório pelo dispositivo e inode, ou pelo caminho
+// absoluto quando o inode não está disponível
+func dirKey(path string, info os.FileInfo) fileKey {
+	dev, ino := fileIdentity(info)
+	if ino == 0 {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			absPath = path
+		}
+		return fileKey{path: absPath}
+	}
+	return fileKey{dev: dev, ino: ino}
+}
+
+// isProtected verifica se o caminho está dentro de algum diretório de referência
+func (s *Scanner) isProtected(path string) bool {
+	if len(s.protectedDirs) == 0 {
+		return false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	for _, dir := range s.protectedDirs {
+		if IsWithin(absPath, dir) {
+			return true
+		}
+	}
+
+	// Um diretório de referência alcançado por um link simbólico seguido continua
+	// protegido: o caminho real é comparado com o caminho real dos diretórios
+	resolved := realPath(absPath)
+	for _, dir := range s.protectedReal {
+		if IsWithin(resolved, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// realPath resolve os links simbólicos de um caminho absoluto, retornando o
+// próprio caminho quando não é possível resolvê-lo
+func realPath(absPath string) string {
+	resolved, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		return absPath
+	}
+	return resolved
+}
+
+// IsWithin verifica se path é dir ou está dentro dele. Os dois caminhos devem ser absolutos.
+func IsWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// SetNullSeparated define se a lista de caminhos lida do stdin é separada por
+// caracteres NUL, como a saída de find -print0, em vez de quebras de linha
+func (s *Scanner) SetNullSeparated(null bool) {
+	if null {
+		s.separator = 0
+	} else {
+		s.separator = '\n'
+	}
+}
+
+// Errors retorna os caminhos da lista que não puderam ser lidos
+func (s *Scanner) Errors() []error {
+	return s.errors
 }
 
 // ScanFromStdin lê uma lista de caminhos de arquivos do stdin
 func (s *Scanner) ScanFromStdin() ([]FileInfo, error) {
-	// Esta funcionalidade será implementada se necessário
-	// Por enquanto, retorna erro indicando que não está implementada
-	return nil, nil
+	return s.ScanFromReaderContext(context.Background(), os.Stdin)
+}
+
+// ScanFromStdinContext lê a lista de caminhos do stdin como ScanFromStdin,
+// interrompendo a leitura quando o contexto é cancelado
+func (s *Scanner) ScanFromStdinContext(ctx context.Context) ([]FileInfo, error) {
+	return s.ScanFromReaderContext(ctx, os.Stdin)
+}
+
+// ScanFromReaderContext lê uma lista de caminhos separados por quebra de linha ou
+// NUL. Os arquivos passam pelos filtros de tamanho e pelos padrões de --exclude e
+// --include e pelos arquivos .redupignore, avaliados a partir do diretório de
+// trabalho; as regras do git não são aplicadas a uma lista escolhida pelo
+// usuário. Diretórios são ignorados e caminhos que não podem ser lidos são
+// registrados em Errors sem interromper a leitura. Os diretórios de referência
+// também são escaneados.
+func (s *Scanner) ScanFromReaderContext(ctx context.Context, r io.Reader) ([]FileInfo, error) {
+	s.resetScan()
+
+	matcher, err := newIgnoreMatcher(".", s.excludes, s.includes, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []FileInfo
+	seen := make(map[string]bool)
+	reader := bufio.NewReader(r)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return files, err
+		}
+
+		path, readErr := reader.ReadString(s.separator)
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return files, readErr
+		}
+
+		path = strings.TrimSuffix(path, string(s.separator))
+		if s.separator == '\n' {
+			path = strings.TrimSuffix(path, "\r")
+		}
+
+		excluded, err := s.listedExcluded(matcher, path)
+		if err != nil {
+			return files, err
+		}
+
+		if path != "" && !excluded {
+			if file, ok := s.statListedFile(path); ok {
+				absPath, err := filepath.Abs(path)
+				if err != nil {
+					absPath = path
+				}
+				if !seen[absPath] {
+					seen[absPath] = true
+					files = append(files, file)
+				}
+			}
+		}
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	// Incluir os arquivos dos diretórios de referência
+	refFiles, err := s.scanRoots(ctx, nil)
+	for _, file := range refFiles {
+		absPath, absErr := filepath.Abs(file.Path)
+		if absErr != nil {
+			absPath = file.Path
+		}
+		if !seen[absPath] {
+			seen[absPath] = true
+			files = append(files, file)
+		}
+	}
+
+	if err != nil {
+		return files, err
+	}
+	return s.applyGitTracked(files)
+}
+
+// listedExcluded verifica se um caminho da lista é excluído pelas regras de
+// exclusão. Caminhos fora do diretório de trabalho não são avaliados.
+func (s *Scanner) listedExcluded(matcher *ignoreMatcher, path string) (bool, error) {
+	if path == "" {
+		return false, nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+
+	inside, err := matcher.loadParents(absPath)
+	if err != nil || !inside {
+		return false, err
+	}
+	return matcher.shouldSkipFile(absPath), nil
+}
+
+// statListedFile obtém as informações de um caminho da lista, aplicando os filtros
+// de tamanho. Retorna false quando o caminho deve ser ignorado.
+func (s *Scanner) statListedFile(path string) (FileInfo, bool) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		s.errors = append(s.errors, fmt.Errorf("failed to stat %s: %w", path, err))
+		return FileInfo{}, false
+	}
+
+	// Links simbólicos nunca são tratados como arquivos
+	if info.Mode()&os.ModeSymlink != 0 {
+		s.symlinks = append(s.symlinks, path)
+		return FileInfo{}, false
+	}
+
+	// Pular diretórios, dispositivos, pipes e sockets
+	if !info.Mode().IsRegular() {
+		return FileInfo{}, false
+	}
+
+	// Verificar tamanho mínimo
+	if s.minSize > 0 && info.Size() < s.minSize {
+		return FileInfo{}, false
+	}
+
+	// Ignorar arquivos vazios (0 bytes)
+	if info.Size() == 0 {
+		return FileInfo{}, false
+	}
+
+	// Confirmar que o arquivo pode ser lido
+	file, err := os.Open(path)
+	if err != nil {
+		s.errors = append(s.errors, fmt.Errorf("failed to open %s: %w", path, err))
+		return FileInfo{}, false
+	}
+	file.Close()
+
+	return s.newFileInfo(path, "", info), true
+}
+
+// newFileInfo monta as informações de um arquivo encontrado na varredura
+func (s *Scanner) newFileInfo(path, root string, info os.FileInfo) FileInfo {
+	dev, ino := fileIdentity(info)
+
+	return FileInfo{
+		Path:      path,
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		Dev:       dev,
+		Inode:     ino,
+		Links:     fileLinkCount(info),
+		Root:      root,
+		Protected: s.isProtected(path),
+	}
+}
+
+// fileKey identifica o conteúdo físico de um arquivo: hard links para o mesmo
+// inode têm a mesma chave. Sem inode conhecido, o caminho identifica o arquivo.
+type fileKey struct {
+	dev, ino uint64
+	path     string
+}
+
+// key retorna a identidade física do arquivo
+func (f FileInfo) key() fileKey {
+	if f.Inode == 0 {
+		return fileKey{path: f.Path}
+	}
+	return fileKey{dev: f.Dev, ino: f.Inode}
+}
+
+// SameInode indica se os dois arquivos são hard links para o mesmo inode
+func (f FileInfo) SameInode(other FileInfo) bool {
+	return f.Inode != 0 && f.Dev == other.Dev && f.Inode == other.Inode
+}
+
+// distinctInodes conta quantos conteúdos físicos diferentes existem na lista
+func distinctInodes(files []FileInfo) int {
+	keys := make(map[fileKey]bool, len(files))
+	for _, file := range files {
+		keys[file.key()] = true
+	}
+	return len(keys)
+}
+
+// hardLinkTargets retorna, para cada arquivo que é hard link de um arquivo
+// anterior da lista, o caminho desse arquivo
+func hardLinkTargets(files []FileInfo) map[int]string {
+	targets := make(map[int]string)
+	first := make(map[fileKey]string, len(files))
+
+	for i, file := range files {
+		key := file.key()
+		if path, exists := first[key]; exists {
+			targets[i] = path
+			continue
+		}
+		first[key] = file.Path
+	}
+
+	return targets
 }
 
 // GetIgnoredRules retorna as regras do .gitignore carregadas (para debug)
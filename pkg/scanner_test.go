@@ -1,8 +1,11 @@
 package pkg
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -36,3 +39,490 @@ func TestScannerIgnoresDotGit(t *testing.T) {
 		t.Errorf("Esperado apenas file1.txt, encontrado: %v", files)
 	}
 }
+
+func TestScanDirectoryContextCancelled(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_scanner_cancel")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "file1.txt"), []byte("abc"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	scanner := NewScanner(0)
+	if _, err := scanner.ScanDirectoryContext(ctx, tmpDir); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestScannerProtectedDirs(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_scanner_protected")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inbox := filepath.Join(tmpDir, "inbox")
+	archive := filepath.Join(tmpDir, "archive")
+	os.MkdirAll(filepath.Join(archive, "2024"), 0755)
+	os.MkdirAll(inbox, 0755)
+	os.WriteFile(filepath.Join(inbox, "photo.jpg"), []byte("abc"), 0644)
+	os.WriteFile(filepath.Join(inbox, ".gitignore"), []byte("*.tmp\n"), 0644)
+	os.WriteFile(filepath.Join(archive, "2024", "photo.jpg"), []byte("abc"), 0644)
+
+	scanner := NewScanner(0)
+	// O subdiretório repetido não deve ser escaneado duas vezes
+	if err := scanner.SetProtectedDirs([]string{archive, filepath.Join(archive, "2024")}); err != nil {
+		t.Fatalf("SetProtectedDirs failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		files, err := scanner.ScanDirectory(inbox)
+		if err != nil {
+			t.Fatalf("ScanDirectory failed: %v", err)
+		}
+
+		protected := map[string]bool{}
+		for _, f := range files {
+			if filepath.Base(f.Path) == "photo.jpg" {
+				protected[f.Path] = f.Protected
+			}
+		}
+		if len(protected) != 2 {
+			t.Fatalf("Expected photo.jpg from inbox and archive, got %v", files)
+		}
+		if protected[filepath.Join(inbox, "photo.jpg")] || !protected[filepath.Join(archive, "2024", "photo.jpg")] {
+			t.Errorf("Expected only the archive copy to be protected, got %v", protected)
+		}
+
+		// As regras do .gitignore não devem se acumular entre varreduras
+		if rules := scanner.GetIgnoredRules(); len(rules) != 1 {
+			t.Errorf("Expected 1 gitignore rule, got %v", rules)
+		}
+	}
+}
+
+func TestScanDirectoriesOverlapping(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_scanner_roots")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dirA := filepath.Join(tmpDir, "a")
+	dirB := filepath.Join(tmpDir, "b")
+	os.MkdirAll(filepath.Join(dirA, "sub"), 0755)
+	os.MkdirAll(dirB, 0755)
+	os.WriteFile(filepath.Join(dirA, "sub", "one.txt"), []byte("abc"), 0644)
+	os.WriteFile(filepath.Join(dirB, "two.txt"), []byte("abc"), 0644)
+	os.WriteFile(filepath.Join(dirB, "skip.log"), []byte("abc"), 0644)
+	os.WriteFile(filepath.Join(dirB, ".gitignore"), []byte("*.log\n"), 0644)
+
+	scanner := NewScanner(0)
+	files, err := scanner.ScanDirectories([]string{filepath.Join(dirA, "sub"), dirA, dirB, dirA})
+	if err != nil {
+		t.Fatalf("ScanDirectories failed: %v", err)
+	}
+
+	roots := map[string]string{}
+	for _, f := range files {
+		if _, exists := roots[filepath.Base(f.Path)]; exists {
+			t.Errorf("File counted twice: %s", f.Path)
+		}
+		roots[filepath.Base(f.Path)] = f.Root
+	}
+
+	expected := map[string]string{"one.txt": dirA, "two.txt": dirB, ".gitignore": dirB}
+	if len(roots) != len(expected) {
+		t.Fatalf("Expected %d files, got %v", len(expected), files)
+	}
+	for name, root := range expected {
+		if roots[name] != root {
+			t.Errorf("Expected %s to be tagged with root %s, got %s", name, root, roots[name])
+		}
+	}
+}
+
+func TestScanFromReader(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_scanner_stdin")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	withSpace := filepath.Join(tmpDir, "with space.txt")
+	small := filepath.Join(tmpDir, "small.txt")
+	empty := filepath.Join(tmpDir, "empty.txt")
+	missing := filepath.Join(tmpDir, "missing.txt")
+	os.WriteFile(withSpace, []byte("content"), 0644)
+	os.WriteFile(small, []byte("c"), 0644)
+	os.WriteFile(empty, nil, 0644)
+
+	paths := []string{withSpace, small, empty, missing, tmpDir, withSpace}
+
+	for _, null := range []bool{false, true} {
+		separator := "\n"
+		if null {
+			separator = "\x00"
+		}
+
+		scanner := NewScanner(2)
+		scanner.SetNullSeparated(null)
+		input := strings.NewReader(strings.Join(paths, separator) + separator)
+
+		files, err := scanner.ScanFromReaderContext(context.Background(), input)
+		if err != nil {
+			t.Fatalf("ScanFromReaderContext failed: %v", err)
+		}
+
+		if len(files) != 1 || files[0].Path != withSpace {
+			t.Errorf("Expected only %s (null: %v), got %v", withSpace, null, files)
+		}
+		if len(scanner.Errors()) != 1 {
+			t.Errorf("Expected 1 error for the missing path (null: %v), got %v", null, scanner.Errors())
+		}
+	}
+}
+
+func TestScannerSymlinks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_scanner_symlinks")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	root := filepath.Join(tmpDir, "root")
+	other := filepath.Join(tmpDir, "other")
+	os.MkdirAll(filepath.Join(root, "sub"), 0755)
+	os.MkdirAll(other, 0755)
+	os.WriteFile(filepath.Join(root, "sub", "file.txt"), []byte("abc"), 0644)
+	os.WriteFile(filepath.Join(other, "outside.txt"), []byte("abc"), 0644)
+
+	if err := os.Symlink(filepath.Join("sub", "file.txt"), filepath.Join(root, "file_link")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	os.Symlink(other, filepath.Join(root, "other_link"))
+	os.Symlink("..", filepath.Join(root, "sub", "loop"))
+
+	// Sem seguir links, apenas o arquivo real é listado
+	scanner := NewScanner(0)
+	files, err := scanner.ScanDirectory(root)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0].Path) != "file.txt" {
+		t.Errorf("Expected only file.txt, got %v", files)
+	}
+	if len(scanner.Symlinks()) != 3 {
+		t.Errorf("Expected 3 symlinks, got %v", scanner.Symlinks())
+	}
+
+	// Seguindo links, o diretório externo entra e o ciclo é detectado
+	scanner.SetFollowSymlinks(true)
+	files, err = scanner.ScanDirectory(root)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("Expected file.txt and outside.txt, got %v", files)
+	}
+	if len(scanner.Symlinks()) != 1 {
+		t.Errorf("Expected only the file symlink to be skipped, got %v", scanner.Symlinks())
+	}
+	if loops := scanner.SymlinkLoops(); len(loops) != 1 || filepath.Base(loops[0]) != "loop" {
+		t.Errorf("Expected the loop to be detected, got %v", loops)
+	}
+}
+
+func TestScannerNestedGitignore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_scanner_nested")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Isolar a configuração do usuário
+	home := filepath.Join(tmpDir, "home")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	os.MkdirAll(filepath.Join(home, ".config", "git"), 0755)
+	os.WriteFile(filepath.Join(home, ".config", "git", "ignore"), []byte("*.global\n"), 0644)
+
+	repo := filepath.Join(tmpDir, "repo")
+	files := map[string]string{
+		".git/info/exclude":    "*.secret\n",
+		".gitignore":           "*.log\n",
+		"pkg/.gitignore":       "!keep.log\n*.tmp\n",
+		"pkg/keep.log":         "x",
+		"pkg/drop.log":         "x",
+		"pkg/cache.tmp":        "x",
+		"pkg/deep/other.tmp":   "x",
+		"pkg/key.secret":       "x",
+		"pkg/settings.global":  "x",
+		"other/build.tmp":      "x",
+		"other/.gitignore":     "/only-here.txt\n",
+		"other/only-here.txt":  "x",
+		"pkg/only-here.txt":    "x",
+		"pkg/deep/regular.txt": "x",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	expected := []string{
+		".gitignore",
+		"pkg/.gitignore",
+		"pkg/keep.log",
+		"pkg/only-here.txt",
+		"pkg/deep/regular.txt",
+		"other/build.tmp",
+		"other/.gitignore",
+	}
+
+	scanner := NewScanner(0)
+	found, err := scanner.ScanDirectory(repo)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, f := range found {
+		rel, _ := filepath.Rel(repo, f.Path)
+		names[filepath.ToSlash(rel)] = true
+	}
+	if len(names) != len(expected) {
+		t.Errorf("Expected %d files, got %v", len(expected), names)
+	}
+	for _, name := range expected {
+		if !names[name] {
+			t.Errorf("Expected %s to be scanned, got %v", name, names)
+		}
+	}
+
+	// Escanear um subdiretório aplica as regras dos diretórios acima dele
+	found, err = scanner.ScanDirectory(filepath.Join(repo, "pkg"))
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+	for _, f := range found {
+		if base := filepath.Base(f.Path); base == "drop.log" || base == "key.secret" || base == "settings.global" {
+			t.Errorf("Expected %s to be ignored when scanning a subdirectory", f.Path)
+		}
+	}
+}
+
+func TestGlobalExcludesFileFromConfig(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_excludes_file")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, ".config"))
+
+	os.WriteFile(filepath.Join(tmpDir, ".gitconfig"), []byte("[user]\n\tname = Test\n[core]\n\texcludesFile = \"~/my ignore\" # comment\n"), 0644)
+	if got := globalExcludesFile(""); got != filepath.Join(tmpDir, "my ignore") {
+		t.Errorf("Expected excludes file from .gitconfig, got %s", got)
+	}
+
+	// A configuração do repositório tem precedência
+	gitDir := filepath.Join(tmpDir, ".git")
+	os.MkdirAll(gitDir, 0755)
+	os.WriteFile(filepath.Join(gitDir, "config"), []byte("[Core]\n\tExcludesFile = /etc/repo-ignore\n"), 0644)
+	if got := globalExcludesFile(gitDir); got != "/etc/repo-ignore" {
+		t.Errorf("Expected excludes file from the repository config, got %s", got)
+	}
+}
+
+func TestScannerIgnoreSources(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_scanner_ignore_sources")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Isolar a configuração do usuário
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "home", ".config"))
+
+	repo := filepath.Join(tmpDir, "repo")
+	os.MkdirAll(filepath.Join(repo, ".git"), 0755)
+	files := map[string]string{
+		".gitignore":                "build/\n*.log\n",
+		".redupignore":              "node_modules/\n*.sqlite\n!keep.log\n",
+		"app.log":                   "x",
+		"keep.log":                  "x",
+		"data.sqlite":               "x",
+		"node_modules/lib/index.js": "x",
+		"build/out/app.o":           "x",
+		"build/out/app.map":         "x",
+		"src/main.go":               "x",
+		"src/main_test.go":          "x",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	tests := []struct {
+		name        string
+		excludes    []string
+		includes    []string
+		noGitignore bool
+		expected    []string
+	}{
+		{
+			name:     "redupignore overrides gitignore",
+			expected: []string{".gitignore", ".redupignore", "keep.log", "src/main.go", "src/main_test.go"},
+		},
+		{
+			name:     "include reaches files in excluded directories",
+			includes: []string{"*.o"},
+			expected: []string{".gitignore", ".redupignore", "keep.log", "build/out/app.o", "src/main.go", "src/main_test.go"},
+		},
+		{
+			name:     "exclude overrides include",
+			excludes: []string{"*_test.go", "/build/out/"},
+			includes: []string{"build/"},
+			expected: []string{".gitignore", ".redupignore", "keep.log", "src/main.go"},
+		},
+		{
+			name:        "no gitignore",
+			noGitignore: true,
+			expected:    []string{".gitignore", ".redupignore", "app.log", "keep.log", "build/out/app.o", "build/out/app.map", "src/main.go", "src/main_test.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(0)
+			if err := scanner.SetExcludePatterns(tt.excludes); err != nil {
+				t.Fatalf("SetExcludePatterns failed: %v", err)
+			}
+			if err := scanner.SetIncludePatterns(tt.includes); err != nil {
+				t.Fatalf("SetIncludePatterns failed: %v", err)
+			}
+			scanner.SetUseGitignore(!tt.noGitignore)
+
+			found, err := scanner.ScanDirectory(repo)
+			if err != nil {
+				t.Fatalf("ScanDirectory failed: %v", err)
+			}
+
+			names := map[string]bool{}
+			for _, f := range found {
+				rel, _ := filepath.Rel(repo, f.Path)
+				names[filepath.ToSlash(rel)] = true
+			}
+			if len(names) != len(tt.expected) {
+				t.Errorf("Expected %d files, got %v", len(tt.expected), names)
+			}
+			for _, name := range tt.expected {
+				if !names[name] {
+					t.Errorf("Expected %s to be scanned, got %v", name, names)
+				}
+			}
+		})
+	}
+
+	// Padrões negados não são aceitos na linha de comando
+	if err := NewScanner(0).SetExcludePatterns([]string{"!*.go"}); err == nil {
+		t.Error("Expected an error for a negated --exclude pattern")
+	}
+}
+
+func TestScannerProtectedThroughSymlink(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_scanner_protected_symlink")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	root := filepath.Join(tmpDir, "root")
+	archive := filepath.Join(tmpDir, "archive")
+	os.MkdirAll(root, 0755)
+	os.MkdirAll(archive, 0755)
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("abc"), 0644)
+	os.WriteFile(filepath.Join(archive, "a.txt"), []byte("abc"), 0644)
+
+	// O diretório de referência é alcançado pela raiz antes de ser escaneado
+	if err := os.Symlink(archive, filepath.Join(root, "ref")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	scanner := NewScanner(0)
+	scanner.SetFollowSymlinks(true)
+	if err := scanner.SetProtectedDirs([]string{archive}); err != nil {
+		t.Fatalf("SetProtectedDirs failed: %v", err)
+	}
+
+	files, err := scanner.ScanDirectory(root)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("Expected a.txt and ref/a.txt, got %v", files)
+	}
+	for _, f := range files {
+		inArchive := f.Path == filepath.Join(root, "ref", "a.txt")
+		if f.Protected != inArchive {
+			t.Errorf("Expected %s to have Protected=%v, got %v", f.Path, inArchive, f.Protected)
+		}
+	}
+}
+
+func TestScanFromReaderIgnoreRules(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_scanner_stdin_ignore")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		".gitignore":         "*.log\n",
+		"sub/.redupignore":   "*.tmp\n",
+		"sub/cache.tmp":      "content",
+		"sub/data.sqlite":    "content",
+		"sub/keep.txt":       "content",
+		"sub/app.log":        "content",
+		"vendor/lib/lib.txt": "content",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	// Os padrões são avaliados a partir do diretório de trabalho
+	wd, _ := os.Getwd()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	scanner := NewScanner(0)
+	scanner.SetExcludePatterns([]string{"*.sqlite", "/vendor/"})
+
+	input := "sub/cache.tmp\nsub/data.sqlite\nsub/keep.txt\nsub/app.log\nvendor/lib/lib.txt\n"
+	found, err := scanner.ScanFromReaderContext(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ScanFromReaderContext failed: %v", err)
+	}
+
+	// As regras do git não valem para a lista escolhida pelo usuário
+	names := map[string]bool{}
+	for _, f := range found {
+		names[filepath.ToSlash(f.Path)] = true
+	}
+	if len(names) != 2 || !names["sub/keep.txt"] || !names["sub/app.log"] {
+		t.Errorf("Expected sub/keep.txt and sub/app.log, got %v", names)
+	}
+}
@@ -0,0 +1,208 @@
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// trashLocation representa uma lixeira da especificação freedesktop.org
+type trashLocation struct {
+	dir    string // diretório da lixeira, contendo files/ e info/
+	topdir string // ponto de montagem para lixeiras por volume; vazio na lixeira do usuário
+}
+
+// TrashFile move um arquivo para a lixeira seguindo a especificação
+// freedesktop.org Trash e retorna o caminho do arquivo dentro da lixeira. Arquivos
+// no mesmo sistema de arquivos que $XDG_DATA_HOME vão para a lixeira do usuário;
+// os demais vão para $topdir/.Trash/$uid ou $topdir/.Trash-$uid do seu volume.
+func TrashFile(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path for %s: %w", path, err)
+	}
+
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return "", err
+	}
+
+	location, err := trashLocationFor(absPath, info)
+	if err != nil {
+		return "", err
+	}
+
+	filesDir := filepath.Join(location.dir, "files")
+	infoDir := filepath.Join(location.dir, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", fmt.Errorf("failed to create trash directory: %w", err)
+		}
+	}
+
+	// Na lixeira de um volume, o caminho original é relativo ao ponto de montagem
+	originalPath := absPath
+	if location.topdir != "" {
+		if rel, err := filepath.Rel(location.topdir, absPath); err == nil {
+			originalPath = rel
+		}
+	}
+
+	// Reservar um nome criando o .trashinfo de forma exclusiva, como exige a especificação
+	name, infoPath, err := createTrashInfo(infoDir, filepath.Base(absPath), originalPath)
+	if err != nil {
+		return "", err
+	}
+
+	trashedPath := filepath.Join(filesDir, name)
+	if err := MoveFile(absPath, trashedPath); err != nil {
+		os.Remove(infoPath)
+		return "", fmt.Errorf("failed to move file to trash: %w", err)
+	}
+
+	return trashedPath, nil
+}
+
+// RestoreFromTrash devolve um arquivo da lixeira ao caminho original e remove
+// o respectivo arquivo .trashinfo
+func RestoreFromTrash(trashedPath, originalPath string) error {
+	if err := os.MkdirAll(filepath.Dir(originalPath), 0755); err != nil {
+		return err
+	}
+
+	if err := MoveFile(trashedPath, originalPath); err != nil {
+		return err
+	}
+
+	trashDir := filepath.Dir(filepath.Dir(trashedPath))
+	infoPath := filepath.Join(trashDir, "info", filepath.Base(trashedPath)+".trashinfo")
+	if err := os.Remove(infoPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// trashLocationFor escolhe a lixeira adequada para um arquivo
+func trashLocationFor(absPath string, info os.FileInfo) (trashLocation, error) {
+	homeTrash, err := homeTrashDir()
+	if err != nil {
+		return trashLocation{}, err
+	}
+
+	fileDev, _ := fileIdentity(info)
+	if homeDev, ok := existingAncestorDevice(homeTrash); ok && homeDev == fileDev {
+		return trashLocation{dir: homeTrash}, nil
+	}
+
+	topdir := mountPoint(absPath, fileDev)
+	uid := strconv.Itoa(os.Getuid())
+
+	// $topdir/.Trash deve ser um diretório real com sticky bit
+	adminTrash := filepath.Join(topdir, ".Trash")
+	if adminInfo, err := os.Lstat(adminTrash); err == nil &&
+		adminInfo.IsDir() && adminInfo.Mode()&os.ModeSticky != 0 {
+		userTrash := filepath.Join(adminTrash, uid)
+		if err := os.MkdirAll(userTrash, 0700); err == nil {
+			return trashLocation{dir: userTrash, topdir: topdir}, nil
+		}
+	}
+
+	userTrash := filepath.Join(topdir, ".Trash-"+uid)
+	if err := os.MkdirAll(userTrash, 0700); err == nil {
+		return trashLocation{dir: userTrash, topdir: topdir}, nil
+	}
+
+	// Sem lixeira no volume, usar a lixeira do usuário copiando o arquivo
+	return trashLocation{dir: homeTrash}, nil
+}
+
+// homeTrashDir retorna $XDG_DATA_HOME/Trash, com o padrão ~/.local/share/Trash
+func homeTrashDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// existingAncestorDevice retorna o dispositivo do caminho ou do ancestral mais
+// próximo que já existe
+func existingAncestorDevice(path string) (uint64, bool) {
+	for {
+		if info, err := os.Stat(path); err == nil {
+			dev, _ := fileIdentity(info)
+			return dev, true
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return 0, false
+		}
+		path = parent
+	}
+}
+
+// mountPoint sobe a partir do arquivo até o último diretório no mesmo dispositivo
+func mountPoint(absPath string, dev uint64) string {
+	dir := filepath.Dir(absPath)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		info, err := os.Stat(parent)
+		if err != nil {
+			return dir
+		}
+		if parentDev, _ := fileIdentity(info); parentDev != dev {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// createTrashInfo cria o arquivo .trashinfo com um nome ainda não usado na
+// lixeira e retorna esse nome e o caminho do arquivo criado
+func createTrashInfo(infoDir, base, originalPath string) (string, string, error) {
+	escaped := (&url.URL{Path: filepath.ToSlash(originalPath)}).EscapedPath()
+	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		escaped, time.Now().Format("2006-01-02T15:04:05"))
+
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	for n := 1; ; n++ {
+		name := base
+		if n > 1 {
+			name = fmt.Sprintf("%s.%d%s", stem, n, ext)
+		}
+
+		infoPath := filepath.Join(infoDir, name+".trashinfo")
+		file, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create trash info: %w", err)
+		}
+
+		_, err = file.WriteString(content)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			return "", "", fmt.Errorf("failed to write trash info: %w", err)
+		}
+
+		return name, infoPath, nil
+	}
+}
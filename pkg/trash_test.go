@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrashFileAndRestore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_trash")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Lixeira do usuário no mesmo sistema de arquivos do arquivo
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tmpDir, "data"))
+
+	original := filepath.Join(tmpDir, "my file.txt")
+	os.WriteFile(original, []byte("trash me"), 0644)
+
+	trashedPath, err := TrashFile(original)
+	if err != nil {
+		t.Fatalf("TrashFile failed: %v", err)
+	}
+
+	expected := filepath.Join(tmpDir, "data", "Trash", "files", "my file.txt")
+	if trashedPath != expected {
+		t.Errorf("Expected trashed path %s, got %s", expected, trashedPath)
+	}
+	if _, err := os.Stat(original); !os.IsNotExist(err) {
+		t.Error("Expected original file to be gone")
+	}
+
+	infoPath := filepath.Join(tmpDir, "data", "Trash", "info", "my file.txt.trashinfo")
+	info, err := os.ReadFile(infoPath)
+	if err != nil {
+		t.Fatalf("Expected trash info file: %v", err)
+	}
+	if !strings.HasPrefix(string(info), "[Trash Info]\n") ||
+		!strings.Contains(string(info), "Path="+filepath.ToSlash(tmpDir)+"/my%20file.txt\n") ||
+		!strings.Contains(string(info), "DeletionDate=") {
+		t.Errorf("Unexpected trash info content:\n%s", info)
+	}
+
+	// Um segundo arquivo com o mesmo nome recebe um nome diferente na lixeira
+	os.WriteFile(original, []byte("trash me too"), 0644)
+	secondPath, err := TrashFile(original)
+	if err != nil {
+		t.Fatalf("TrashFile failed for second file: %v", err)
+	}
+	if secondPath == trashedPath {
+		t.Error("Expected a unique name for the second trashed file")
+	}
+
+	if err := RestoreFromTrash(trashedPath, original); err != nil {
+		t.Fatalf("RestoreFromTrash failed: %v", err)
+	}
+	if content, _ := os.ReadFile(original); string(content) != "trash me" {
+		t.Errorf("Unexpected restored content: %q", content)
+	}
+	if _, err := os.Stat(infoPath); !os.IsNotExist(err) {
+		t.Error("Expected trash info file to be removed after restore")
+	}
+}
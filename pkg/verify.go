@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// verifyBufferSize é o tamanho dos blocos lidos na comparação byte a byte
+const verifyBufferSize = 64 * 1024
+
+// FilesEqual compara o conteúdo de dois arquivos byte a byte
+func FilesEqual(pathA, pathB string) (bool, error) {
+	fileA, err := os.Open(pathA)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file %s: %w", pathA, err)
+	}
+	defer fileA.Close()
+
+	fileB, err := os.Open(pathB)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file %s: %w", pathB, err)
+	}
+	defer fileB.Close()
+
+	infoA, err := fileA.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat file %s: %w", pathA, err)
+	}
+	infoB, err := fileB.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat file %s: %w", pathB, err)
+	}
+
+	// Tamanhos diferentes dispensam a leitura do conteúdo
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+
+	bufA := make([]byte, verifyBufferSize)
+	bufB := make([]byte, verifyBufferSize)
+
+	for {
+		nA, errA := io.ReadFull(fileA, bufA)
+		nB, errB := io.ReadFull(fileB, bufB)
+
+		if errA != nil && errA != io.EOF && errA != io.ErrUnexpectedEOF {
+			return false, fmt.Errorf("failed to read file %s: %w", pathA, errA)
+		}
+		if errB != nil && errB != io.EOF && errB != io.ErrUnexpectedEOF {
+			return false, fmt.Errorf("failed to read file %s: %w", pathB, errB)
+		}
+
+		if nA != nB || !bytes.Equal(bufA[:nA], bufB[:nB]) {
+			return false, nil
+		}
+
+		// Fim de ambos os arquivos
+		if errA != nil || errB != nil {
+			return errA != nil && errB != nil, nil
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilesEqual(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_verify")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Conteúdo maior que o buffer de comparação
+	large := make([]byte, verifyBufferSize*2+10)
+	largeChanged := append([]byte{}, large...)
+	largeChanged[len(largeChanged)-1] = 1
+
+	files := map[string][]byte{
+		"a":             []byte("same content"),
+		"b":             []byte("same content"),
+		"c":             []byte("diff content"),
+		"short":         []byte("same"),
+		"large":         large,
+		"large_copy":    large,
+		"large_changed": largeChanged,
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(tmpDir, name), content, 0644)
+	}
+
+	tests := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"a", "b", true},
+		{"a", "c", false},
+		{"a", "short", false},
+		{"large", "large_copy", true},
+		{"large", "large_changed", false},
+	}
+
+	for _, tt := range tests {
+		equal, err := FilesEqual(filepath.Join(tmpDir, tt.a), filepath.Join(tmpDir, tt.b))
+		if err != nil {
+			t.Errorf("FilesEqual(%s, %s) failed: %v", tt.a, tt.b, err)
+			continue
+		}
+		if equal != tt.equal {
+			t.Errorf("FilesEqual(%s, %s) = %v, expected %v", tt.a, tt.b, equal, tt.equal)
+		}
+	}
+
+	if _, err := FilesEqual(filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "missing")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestMoveFileToBackupVerifyMismatch(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test_verify_move")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	kept := filepath.Join(tmpDir, "kept.txt")
+	duplicate := filepath.Join(tmpDir, "duplicate.txt")
+	os.WriteFile(kept, []byte("original"), 0644)
+	os.WriteFile(duplicate, []byte("collided"), 0644)
+
+	manager := NewManager(tmpDir, true)
+	manager.SetVerify(true)
+	manager.logFile = filepath.Join(tmpDir, "log.csv")
+
+	backupPath := filepath.Join(tmpDir, "backup")
+	if err := manager.moveFileToBackup(duplicate, backupPath, kept, "checksum"); err == nil {
+		t.Fatal("Expected verification error for different content")
+	}
+
+	// O arquivo deve permanecer no lugar e nada deve ser registrado
+	if _, err := os.Stat(duplicate); err != nil {
+		t.Errorf("Expected duplicate to remain in place: %v", err)
+	}
+	if _, err := os.Stat(manager.logFile); !os.IsNotExist(err) {
+		t.Error("Expected no CSV log entry after refused move")
+	}
+}
@@ -0,0 +1,165 @@
+package pkg
+
+import "unicode"
+
+// wildmatch compara um nome (sem "/") com um padrão glob no formato do git:
+// "*" corresponde a qualquer sequência, "?" a um caractere, "[...]" a um conjunto
+// (com "!" ou "^" para negar, intervalos e classes como [:alpha:]) e "\" torna o
+// caractere seguinte literal
+func wildmatch(pattern, name string) bool {
+	p := []rune(pattern)
+	n := []rune(name)
+
+	px, nx := 0, 0
+	starP, starN := -1, -1
+
+	for nx < len(n) {
+		if px < len(p) {
+			switch p[px] {
+			case '*':
+				// Asteriscos consecutivos equivalem a um só dentro de um nome
+				for px < len(p) && p[px] == '*' {
+					px++
+				}
+				starP, starN = px, nx
+				continue
+			case '?':
+				px++
+				nx++
+				continue
+			case '[':
+				if matched, width, valid := matchClass(p[px:], n[nx]); valid {
+					if matched {
+						px += width
+						nx++
+						continue
+					}
+				} else if n[nx] == '[' {
+					px++
+					nx++
+					continue
+				}
+			case '\\':
+				if px+1 < len(p) && p[px+1] == n[nx] {
+					px += 2
+					nx++
+					continue
+				}
+			default:
+				if p[px] == n[nx] {
+					px++
+					nx++
+					continue
+				}
+			}
+		}
+
+		// Voltar ao último "*" e fazê-lo consumir mais um caractere
+		if starP >= 0 {
+			starN++
+			px, nx = starP, starN
+			continue
+		}
+		return false
+	}
+
+	for px < len(p) && p[px] == '*' {
+		px++
+	}
+	return px == len(p)
+}
+
+// matchClass avalia um conjunto "[...]" no início de p. Retorna se c pertence ao
+// conjunto, quantos caracteres do padrão o conjunto ocupa e se ele é válido.
+func matchClass(p []rune, c rune) (matched bool, width int, valid bool) {
+	i := 1
+	negate := false
+	if i < len(p) && (p[i] == '!' || p[i] == '^') {
+		negate = true
+		i++
+	}
+
+	first := true
+	for i < len(p) {
+		// "]" logo após a abertura é um caractere do conjunto
+		if p[i] == ']' && !first {
+			return matched != negate, i + 1, true
+		}
+		first = false
+
+		// Classes POSIX, como [:digit:]
+		if p[i] == '[' && i+1 < len(p) && p[i+1] == ':' {
+			if end := indexClassEnd(p, i+2); end >= 0 {
+				if posixClass(string(p[i+2:end]), c) {
+					matched = true
+				}
+				i = end + 2
+				continue
+			}
+		}
+
+		lo := p[i]
+		if lo == '\\' && i+1 < len(p) {
+			i++
+			lo = p[i]
+		}
+		i++
+
+		hi := lo
+		if i+1 < len(p) && p[i] == '-' && p[i+1] != ']' {
+			i++
+			if p[i] == '\\' && i+1 < len(p) {
+				i++
+			}
+			hi = p[i]
+			i++
+		}
+
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+
+	return false, 0, false
+}
+
+// indexClassEnd retorna a posição de ":]" a partir de start, ou -1
+func indexClassEnd(p []rune, start int) int {
+	for i := start; i+1 < len(p); i++ {
+		if p[i] == ':' && p[i+1] == ']' {
+			return i
+		}
+	}
+	return -1
+}
+
+// posixClass verifica se c pertence à classe POSIX informada
+func posixClass(class string, c rune) bool {
+	switch class {
+	case "alnum":
+		return unicode.IsLetter(c) || unicode.IsDigit(c)
+	case "alpha":
+		return unicode.IsLetter(c)
+	case "blank":
+		return c == ' ' || c == '\t'
+	case "cntrl":
+		return unicode.IsControl(c)
+	case "digit":
+		return unicode.IsDigit(c)
+	case "graph":
+		return unicode.IsGraphic(c) && !unicode.IsSpace(c)
+	case "lower":
+		return unicode.IsLower(c)
+	case "print":
+		return unicode.IsPrint(c)
+	case "punct":
+		return unicode.IsPunct(c)
+	case "space":
+		return unicode.IsSpace(c)
+	case "upper":
+		return unicode.IsUpper(c)
+	case "xdigit":
+		return unicode.Is(unicode.ASCII_Hex_Digit, c)
+	}
+	return false
+}